@@ -0,0 +1,135 @@
+package automod
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Acciones para cuentas más nuevas que el mínimo
+const (
+	AgeActionKick       = "kick"
+	AgeActionTimeout    = "timeout"
+	AgeActionQuarantine = "quarantine"
+	AgeActionRestrict   = "restrict"
+)
+
+// Discord no permite aislamientos de más de 28 días
+const maxTimeout = 28 * 24 * time.Hour
+
+type AccountAgeConfig struct {
+	// Edad mínima de la cuenta en horas; 0 desactiva el control
+	MinHours int    `json:"min_hours"`
+	Action   string `json:"action"`
+}
+
+var DefaultAccountAgeConfig = AccountAgeConfig{
+	MinHours: 0,
+	Action:   AgeActionRestrict,
+}
+
+func (m *Manager) accountAgeConfig(guildID string) AccountAgeConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok && cfg.AccountAge != nil {
+		return *cfg.AccountAge
+	}
+	return DefaultAccountAgeConfig
+}
+
+func (m *Manager) SetAccountAge(guildID string, hours int, action string) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	if m.GuildConfig[guildID].AccountAge == nil {
+		age := DefaultAccountAgeConfig
+		m.GuildConfig[guildID].AccountAge = &age
+	}
+	if hours >= 0 {
+		m.GuildConfig[guildID].AccountAge.MinHours = hours
+	}
+	if action != "" {
+		m.GuildConfig[guildID].AccountAge.Action = action
+	}
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+// checkAccountAge aplica la política del servidor a las cuentas que todavía
+// no tienen la edad mínima. "restrict" no hace nada al entrar: se aplica en
+// checkNewAccount sobre cada mensaje.
+func (m *Manager) checkAccountAge(s *discordgo.Session, ev *discordgo.GuildMemberAdd) {
+	cfg := m.accountAgeConfig(ev.GuildID)
+	minAge := time.Duration(cfg.MinHours) * time.Hour
+	age := accountAge(ev.User.ID)
+	if minAge == 0 || age >= minAge {
+		return
+	}
+	remaining := minAge - age
+
+	var result string
+	var err error
+	switch cfg.Action {
+	case AgeActionKick:
+		if ch, dmErr := s.UserChannelCreate(ev.User.ID); dmErr == nil {
+			s.ChannelMessageSend(ch.ID, fmt.Sprintf("Tu cuenta es demasiado nueva para entrar a este servidor. Vuelve a intentarlo <t:%d:R>.",
+				time.Now().Add(remaining).Unix()))
+		}
+		err = s.GuildMemberDeleteWithReason(ev.GuildID, ev.User.ID, "Cuenta más nueva que el mínimo")
+		result = "Expulsado"
+	case AgeActionTimeout:
+		until := time.Now().Add(min(remaining, maxTimeout))
+		err = s.GuildMemberTimeout(ev.GuildID, ev.User.ID, &until)
+		result = fmt.Sprintf("Aislado hasta <t:%d:f>", until.Unix())
+	case AgeActionQuarantine:
+		err = m.QuarantineMember(s, ev.GuildID, ev.User.ID, "Cuenta más nueva que el mínimo", "Sentinel", remaining)
+		result = fmt.Sprintf("En cuarentena hasta <t:%d:f>", time.Now().Add(remaining).Unix())
+	case AgeActionRestrict:
+		result = fmt.Sprintf("Sin links ni adjuntos hasta <t:%d:f>", time.Now().Add(remaining).Unix())
+	default:
+		return
+	}
+	if err != nil {
+		fmt.Printf("Error aplicando edad mínima a %s: %v\n", ev.User.ID, err)
+		result += fmt.Sprintf(" (falló: %v)", err)
+	}
+
+	m.LogEvent(s, ev.GuildID, &discordgo.MessageEmbed{
+		Title: "👶 Cuenta Nueva",
+		Description: fmt.Sprintf("Usuario: <@%s> (%s)\nCuenta creada: <t:%d:R>\nMotivo: la cuenta tiene %s y el mínimo es %dh\nAcción: %s",
+			ev.User.ID, ev.User.String(), time.Now().Add(-age).Unix(), age.Round(time.Minute), cfg.MinHours, result),
+		Color:     0xf39c12,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// checkNewAccount borra links y adjuntos de cuentas que todavía no tienen la
+// edad mínima, si la política del servidor es "restrict".
+func (m *Manager) checkNewAccount(msg *discordgo.MessageCreate) *Detection {
+	cfg := m.accountAgeConfig(msg.GuildID)
+	if cfg.Action != AgeActionRestrict || cfg.MinHours == 0 {
+		return nil
+	}
+	minAge := time.Duration(cfg.MinHours) * time.Hour
+	age := accountAge(msg.Author.ID)
+	if age >= minAge {
+		return nil
+	}
+
+	var what string
+	switch {
+	case len(msg.Attachments) > 0:
+		what = "adjuntos"
+	case len(ExtractLinks(msg.Content, nil)) > 0 || len(ExtractInviteCodes(msg.Content)) > 0:
+		what = "links"
+	default:
+		return nil
+	}
+	return &Detection{
+		Reason: "Cuenta Nueva",
+		Detail: fmt.Sprintf("La cuenta tiene %s y no puede enviar %s hasta cumplir %dh (<t:%d:R>).",
+			age.Round(time.Minute), what, cfg.MinHours, time.Now().Add(minAge-age).Unix()),
+	}
+}
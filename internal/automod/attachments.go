@@ -0,0 +1,303 @@
+package automod
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Tamaño máximo que se descarga completo para hashear y listar zips
+	attachmentScanMax = 16 << 20
+	// Bytes que se leen para reconocer la firma de imágenes y archivos grandes
+	attachmentSniffBytes = 64 << 10
+	zipMaxEntries        = 2000
+)
+
+var attachmentClient = &http.Client{Timeout: 30 * time.Second}
+
+type AttachmentConfig struct {
+	Enabled bool `json:"enabled"`
+	// Extensiones sin punto que se bloquean, sueltas o dentro de un zip
+	BlockedExtensions []string `json:"blocked_extensions"`
+	// Bloquear ejecutables reconocidos por firma aunque tengan otra extensión
+	BlockExecutables       bool `json:"block_executables"`
+	BlockEncryptedArchives bool `json:"block_encrypted_archives"`
+	// 0 solo borra el mensaje y avisa en el canal de logs
+	MuteHours int `json:"mute_hours"`
+}
+
+var DefaultAttachmentConfig = AttachmentConfig{
+	Enabled: true,
+	BlockedExtensions: []string{
+		"exe", "scr", "com", "pif", "msi", "msix", "appx", "dll", "cpl",
+		"vbs", "vbe", "jse", "wsf", "wsh", "hta", "lnk", "reg", "jar",
+		"iso", "img", "vhd", "vhdx",
+	},
+	BlockExecutables:       true,
+	BlockEncryptedArchives: true,
+}
+
+// executableExtensions son las que Windows ejecuta al abrir; detrás de una
+// extensión de documento delatan una doble extensión aunque no estén bloqueadas.
+var executableExtensions = map[string]bool{
+	"exe": true, "scr": true, "com": true, "pif": true, "msi": true, "bat": true,
+	"cmd": true, "ps1": true, "js": true, "jse": true, "vbs": true, "vbe": true,
+	"wsf": true, "hta": true, "lnk": true, "cpl": true, "jar": true,
+}
+
+var documentExtensions = map[string]bool{
+	"pdf": true, "doc": true, "docx": true, "xls": true, "xlsx": true, "ppt": true,
+	"pptx": true, "txt": true, "rtf": true, "jpg": true, "jpeg": true, "png": true,
+	"gif": true, "webp": true, "mp3": true, "mp4": true, "mov": true, "zip": true,
+	"rar": true,
+}
+
+var imageExtensions = map[string]bool{
+	"jpg": true, "jpeg": true, "png": true, "gif": true, "webp": true,
+}
+
+type signature struct {
+	kind       string
+	offset     int
+	magic      []byte
+	executable bool
+}
+
+var signatures = []signature{
+	{"ejecutable de Windows (PE)", 0, []byte("MZ"), true},
+	{"ejecutable ELF", 0, []byte("\x7fELF"), true},
+	{"ejecutable Mach-O", 0, []byte{0xcf, 0xfa, 0xed, 0xfe}, true},
+	{"ejecutable Mach-O", 0, []byte{0xce, 0xfa, 0xed, 0xfe}, true},
+	{"acceso directo de Windows (LNK)", 0, []byte{0x4c, 0, 0, 0, 0x01, 0x14, 0x02, 0}, true},
+	{"zip", 0, []byte("PK\x03\x04"), false},
+	{"rar", 0, []byte("Rar!\x1a\x07"), false},
+	{"7z", 0, []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}, false},
+	{"cab", 0, []byte("MSCF"), false},
+	{"documento OLE (msi, doc)", 0, []byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1}, false},
+	{"imagen de disco ISO", 0x8001, []byte("CD001"), false},
+}
+
+// sniff reconoce el tipo real del archivo por sus primeros bytes.
+func sniff(data []byte) (signature, bool) {
+	for _, sig := range signatures {
+		end := sig.offset + len(sig.magic)
+		if len(data) < end || !bytes.Equal(data[sig.offset:end], sig.magic) {
+			continue
+		}
+		// "MZ" es corto: confirmar la cabecera PE
+		if sig.magic[0] == 'M' && !hasPEHeader(data) {
+			continue
+		}
+		return sig, true
+	}
+	return signature{}, false
+}
+
+func hasPEHeader(data []byte) bool {
+	if len(data) < 0x40 {
+		return false
+	}
+	offset := int64(binary.LittleEndian.Uint32(data[0x3c:]))
+	return offset+4 <= int64(len(data)) && bytes.Equal(data[offset:offset+4], []byte("PE\x00\x00"))
+}
+
+func extensions(name string) []string {
+	parts := strings.Split(strings.ToLower(path.Base(name)), ".")
+	if len(parts) < 2 {
+		return nil
+	}
+	exts := parts[1:]
+	for i := range exts {
+		exts[i] = strings.TrimSpace(exts[i])
+	}
+	return exts
+}
+
+// checkFileName revisa el nombre de un adjunto o de una entrada de zip y
+// devuelve el motivo si no está permitido.
+func checkFileName(name string, cfg AttachmentConfig) string {
+	if strings.ContainsRune(name, '\u202e') {
+		return "Nombre con carácter de inversión de texto (RLO)"
+	}
+	exts := extensions(name)
+	if len(exts) == 0 {
+		return ""
+	}
+	last := exts[len(exts)-1]
+	if len(exts) >= 2 && documentExtensions[exts[len(exts)-2]] && executableExtensions[last] {
+		return fmt.Sprintf("Doble extensión (`.%s.%s`)", exts[len(exts)-2], last)
+	}
+	if slices.Contains(cfg.BlockedExtensions, last) {
+		return fmt.Sprintf("Extensión bloqueada (`.%s`)", last)
+	}
+	return ""
+}
+
+// inspectZip lista las entradas sin descomprimirlas.
+func inspectZip(data []byte, cfg AttachmentConfig) string {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return ""
+	}
+	for i, f := range reader.File {
+		if i >= zipMaxEntries {
+			break
+		}
+		if f.Flags&0x1 != 0 && cfg.BlockEncryptedArchives {
+			return fmt.Sprintf("Zip protegido con contraseña (`%s`)", truncate(f.Name, 100))
+		}
+		if reason := checkFileName(f.Name, cfg); reason != "" {
+			return fmt.Sprintf("%s dentro del zip: `%s`", reason, truncate(f.Name, 100))
+		}
+	}
+	return ""
+}
+
+func NewHashList(path string) *DomainList {
+	return newList(path, func(s string) string {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if _, err := hex.DecodeString(s); err != nil || len(s) != sha256.Size*2 {
+			return ""
+		}
+		return s
+	})
+}
+
+// LoadHashList carga la lista de SHA-256 de archivos maliciosos conocidos,
+// en formato de sha256sum o un hash por línea.
+func (m *Manager) LoadHashList(path string) {
+	m.MaliciousHashes = NewHashList(path)
+}
+
+func (m *Manager) attachmentConfig(guildID string) AttachmentConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok && cfg.Attachments != nil {
+		return *cfg.Attachments
+	}
+	return DefaultAttachmentConfig
+}
+
+func (m *Manager) SetAttachmentFilter(guildID string, enabled bool) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	if m.GuildConfig[guildID].Attachments == nil {
+		att := DefaultAttachmentConfig
+		m.GuildConfig[guildID].Attachments = &att
+	}
+	m.GuildConfig[guildID].Attachments.Enabled = enabled
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+func downloadAttachment(url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if limit < attachmentScanMax {
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", limit-1))
+	}
+	resp, err := attachmentClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("Falla descargando adjunto: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+// scanAttachment descarga el adjunto (o su comienzo si es una imagen o es
+// muy grande) y revisa firma, contenido de zips y lista de hashes.
+func (m *Manager) scanAttachment(att *discordgo.MessageAttachment, cfg AttachmentConfig) string {
+	exts := extensions(att.Filename)
+	var ext string
+	if len(exts) > 0 {
+		ext = exts[len(exts)-1]
+	}
+
+	limit := int64(attachmentScanMax)
+	full := att.Size <= attachmentScanMax
+	if imageExtensions[ext] || !full {
+		limit, full = attachmentSniffBytes, false
+	}
+
+	data, err := downloadAttachment(att.URL, limit)
+	if err != nil {
+		fmt.Printf("Error descargando adjunto %s: %v\n", att.Filename, err)
+		return ""
+	}
+
+	if full && m.MaliciousHashes != nil {
+		sum := sha256.Sum256(data)
+		if match, ok := m.MaliciousHashes.Match(hex.EncodeToString(sum[:])); ok {
+			return fmt.Sprintf("Archivo malicioso conocido (SHA-256 `%s`)", match)
+		}
+	}
+
+	sig, ok := sniff(data)
+	if !ok {
+		return ""
+	}
+	if sig.executable && cfg.BlockExecutables {
+		if ext == "" || !executableExtensions[ext] {
+			return fmt.Sprintf("Es un %s con extensión `.%s`", sig.kind, ext)
+		}
+		return fmt.Sprintf("Es un %s", sig.kind)
+	}
+	if sig.kind == "zip" && full {
+		return inspectZip(data, cfg)
+	}
+	return ""
+}
+
+// checkAttachments revisa los nombres de los adjuntos en el momento y su
+// contenido en segundo plano, sancionando por su cuenta si encuentra algo.
+func (m *Manager) checkAttachments(s *discordgo.Session, msg *discordgo.MessageCreate) *Detection {
+	if len(msg.Attachments) == 0 {
+		return nil
+	}
+	cfg := m.attachmentConfig(msg.GuildID)
+	if !cfg.Enabled {
+		return nil
+	}
+	mute := time.Duration(cfg.MuteHours) * time.Hour
+
+	for _, att := range msg.Attachments {
+		if reason := checkFileName(att.Filename, cfg); reason != "" {
+			return &Detection{
+				Reason: "Adjunto Peligroso",
+				Detail: fmt.Sprintf("%s\nArchivo: `%s`", reason, truncate(att.Filename, 100)),
+				Mute:   mute,
+			}
+		}
+	}
+
+	go func() {
+		for _, att := range msg.Attachments {
+			if reason := m.scanAttachment(att, cfg); reason != "" {
+				detail := fmt.Sprintf("%s\nArchivo: `%s` (%s)", reason, truncate(att.Filename, 100), formatMemory(float64(att.Size)))
+				m.TakeAction(s, msg, "Adjunto Peligroso", detail, mute, nil)
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,275 @@
+package automod
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/corona10/goimagehash"
+)
+
+// Acciones para avatares que coinciden con la biblioteca
+const (
+	AvatarActionKick       = "kick"
+	AvatarActionQuarantine = "quarantine"
+	AvatarActionAlert      = "alert"
+)
+
+// Bibliotecas contra las que se comparan los avatares
+const (
+	AvatarLibraryScam = "scam"
+	AvatarLibraryBad  = "avatar"
+)
+
+// Distancias de Hamming entre phash de 64 bits: hasta avatarHashMatch es la
+// misma imagen; hasta avatarHashMaybe se parece y se confirma con CLIP.
+const (
+	avatarHashMatch = 6
+	avatarHashMaybe = 16
+	avatarClipMatch = 0.92
+	avatarCacheSize = 5000
+)
+
+type AvatarConfig struct {
+	Enabled bool   `json:"enabled"`
+	Action  string `json:"action"`
+}
+
+var DefaultAvatarConfig = AvatarConfig{
+	Enabled: true,
+	Action:  AvatarActionAlert,
+}
+
+type avatarEntry struct {
+	name      string
+	library   string
+	hash      *goimagehash.ImageHash
+	embedding []float32
+}
+
+type AvatarMatch struct {
+	Name     string
+	Library  string
+	Distance int
+	// Similitud CLIP, solo si hizo falta confirmar
+	Score float32
+}
+
+// avatarScan es el resultado de analizar un avatar; se guarda por URL para no
+// descargar dos veces el mismo.
+type avatarScan struct {
+	hash  *goimagehash.ImageHash
+	match *AvatarMatch
+	image []byte
+}
+
+// AvatarLibrary guarda el phash y el embedding CLIP de las imágenes de scam y
+// de los avatares conocidos de cuentas maliciosas.
+type AvatarLibrary struct {
+	mu      sync.RWMutex
+	entries []avatarEntry
+	cache   map[string]*avatarScan
+}
+
+func NewAvatarLibrary() *AvatarLibrary {
+	return &AvatarLibrary{cache: make(map[string]*avatarScan)}
+}
+
+// LoadAvatarLibrary carga las dos bibliotecas. La de avatares es opcional.
+func (m *Manager) LoadAvatarLibrary(scamDir, avatarDir string) {
+	var entries []avatarEntry
+	for library, dir := range map[string]string{AvatarLibraryScam: scamDir, AvatarLibraryBad: avatarDir} {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				fmt.Printf("Error leyendo %s: %v\n", dir, err)
+			}
+			continue
+		}
+		for _, file := range files {
+			if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+				continue
+			}
+			path := filepath.Join(dir, file.Name())
+			img, err := loadImage(path)
+			if err != nil {
+				fmt.Printf("Error cargando %s: %v\n", path, err)
+				continue
+			}
+			hash, err := goimagehash.PerceptionHash(img)
+			if err != nil {
+				fmt.Printf("Error calculando phash de %s: %v\n", path, err)
+				continue
+			}
+			entries = append(entries, avatarEntry{
+				name:      file.Name(),
+				library:   library,
+				hash:      hash,
+				embedding: m.Scanner.Embed(img),
+			})
+		}
+	}
+
+	a := m.Avatars
+	a.mu.Lock()
+	a.entries = entries
+	a.cache = make(map[string]*avatarScan)
+	a.mu.Unlock()
+
+	fmt.Printf("Cargadas %d imágenes para comparar avatares\n", len(entries))
+}
+
+// Match compara primero por phash y solo corre CLIP si el mejor candidato
+// queda en la zona dudosa.
+func (a *AvatarLibrary) Match(scanner *CLIPScanner, img image.Image, hash *goimagehash.ImageHash) *AvatarMatch {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var candidates []avatarEntry
+	var best *AvatarMatch
+	for _, entry := range a.entries {
+		dist, err := hash.Distance(entry.hash)
+		if err != nil || dist > avatarHashMaybe {
+			continue
+		}
+		if dist <= avatarHashMatch && (best == nil || dist < best.Distance) {
+			best = &AvatarMatch{Name: entry.name, Library: entry.library, Distance: dist}
+		}
+		candidates = append(candidates, entry)
+	}
+	if best != nil || len(candidates) == 0 {
+		return best
+	}
+
+	emb := scanner.Embed(img)
+	for _, entry := range candidates {
+		score := cosineSimilarity(emb, entry.embedding)
+		if score >= avatarClipMatch && (best == nil || score > best.Score) {
+			dist, _ := hash.Distance(entry.hash)
+			best = &AvatarMatch{Name: entry.name, Library: entry.library, Distance: dist, Score: score}
+		}
+	}
+	return best
+}
+
+// scanAvatar descarga el avatar visible del miembro en el servidor y lo
+// compara con la biblioteca. Devuelve nil si usa el avatar por defecto.
+func (m *Manager) scanAvatar(member *discordgo.Member) *avatarScan {
+	if member == nil || member.User == nil || (member.Avatar == "" && member.User.Avatar == "") {
+		return nil
+	}
+	url := member.AvatarURL("256")
+
+	a := m.Avatars
+	a.mu.RLock()
+	scan, ok := a.cache[url]
+	a.mu.RUnlock()
+	if ok {
+		return scan
+	}
+
+	img, err := DownloadImage(url)
+	if err != nil {
+		fmt.Printf("Error descargando avatar de %s: %v\n", member.User.ID, err)
+		return nil
+	}
+	hash, err := goimagehash.PerceptionHash(img)
+	if err != nil {
+		return nil
+	}
+	scan = &avatarScan{hash: hash, match: a.Match(m.Scanner, img, hash)}
+	if scan.match != nil {
+		var buf bytes.Buffer
+		jpeg.Encode(&buf, img, &jpeg.Options{Quality: 75})
+		scan.image = buf.Bytes()
+	}
+
+	a.mu.Lock()
+	if len(a.cache) >= avatarCacheSize {
+		a.cache = make(map[string]*avatarScan)
+	}
+	a.cache[url] = scan
+	a.mu.Unlock()
+	return scan
+}
+
+func (m *Manager) avatarConfig(guildID string) AvatarConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok && cfg.Avatars != nil {
+		return *cfg.Avatars
+	}
+	return DefaultAvatarConfig
+}
+
+func (m *Manager) SetAvatarScreening(guildID, action string) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	if m.GuildConfig[guildID].Avatars == nil {
+		avatars := DefaultAvatarConfig
+		m.GuildConfig[guildID].Avatars = &avatars
+	}
+	m.GuildConfig[guildID].Avatars.Enabled = action != ""
+	if action != "" {
+		m.GuildConfig[guildID].Avatars.Action = action
+	}
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+// checkAvatar aplica la acción configurada si el avatar coincide con la biblioteca.
+func (m *Manager) checkAvatar(s *discordgo.Session, guildID string, member *discordgo.Member, scan *avatarScan) {
+	if scan == nil || scan.match == nil || member.User.Bot {
+		return
+	}
+	cfg := m.avatarConfig(guildID)
+	if !cfg.Enabled || m.isStaff(guildID, member) {
+		return
+	}
+
+	var result string
+	var err error
+	switch cfg.Action {
+	case AvatarActionKick:
+		err = s.GuildMemberDeleteWithReason(guildID, member.User.ID, "Avatar de cuenta maliciosa")
+		result = "Expulsado"
+	case AvatarActionQuarantine:
+		err = m.QuarantineMember(s, guildID, member.User.ID, "Avatar sospechoso", "Sentinel", 0)
+		result = "En cuarentena hasta /release"
+	default:
+		result = "Solo aviso"
+	}
+	if err != nil {
+		fmt.Printf("Error moderando el avatar de %s: %v\n", member.User.ID, err)
+		result += fmt.Sprintf(" (falló: %v)", err)
+	}
+
+	library := "imágenes de scam"
+	if scan.match.Library == AvatarLibraryBad {
+		library = "avatares maliciosos"
+	}
+	detail := fmt.Sprintf("Coincide con `%s` (%s)\nDistancia phash: %d", scan.match.Name, library, scan.match.Distance)
+	if scan.match.Score > 0 {
+		detail += fmt.Sprintf("\nScore CLIP: %.3f", scan.match.Score)
+	}
+	detail += "\nAcción: " + result
+	m.LogSanction(s, guildID, member.User, "Avatar Sospechoso", detail, scan.image)
+}
+
+// sameAvatar indica si dos avatares son la misma imagen aunque se hayan
+// vuelto a subir o recomprimir.
+func sameAvatar(a, b *goimagehash.ImageHash) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	dist, err := a.Distance(b)
+	return err == nil && dist <= avatarHashMatch
+}
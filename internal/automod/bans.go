@@ -0,0 +1,361 @@
+package automod
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/corona10/goimagehash"
+)
+
+// El CustomID del botón lleva el ID del usuario a banear después del prefijo.
+const BanEvasionButtonPrefix = "ban_evasion:"
+
+// Baneos guardados por servidor; los más viejos se descartan primero
+const maxBanFingerprints = 1000
+
+type BanEvasionConfig struct {
+	Enabled bool `json:"enabled"`
+	// Puntaje a partir del cual se avisa a los moderadores
+	Threshold int `json:"threshold"`
+	// Días que se recuerda a un usuario baneado
+	RetentionDays int `json:"retention_days"`
+}
+
+var DefaultBanEvasionConfig = BanEvasionConfig{
+	Enabled:       true,
+	Threshold:     60,
+	RetentionDays: 90,
+}
+
+// BanFingerprint es lo que se recuerda de un usuario baneado para reconocer
+// sus cuentas nuevas.
+type BanFingerprint struct {
+	UserID      string    `json:"user_id"`
+	Username    string    `json:"username"`
+	NameBase    string    `json:"name_base"`
+	DisplayBase string    `json:"display_base,omitempty"`
+	AvatarHash  uint64    `json:"avatar_hash,string,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	JoinedAt    time.Time `json:"joined_at"`
+	BannedAt    time.Time `json:"banned_at"`
+}
+
+// memberSnapshotTTL es cuánto se recuerda a un miembro desde la última vez
+// que se lo vio entrar, escribir o salir.
+const memberSnapshotTTL = 24 * time.Hour
+
+// memberSnapshots guarda copias de los miembros vistos hace poco. Discord suele
+// mandar GUILD_MEMBER_REMOVE antes que GUILD_BAN_ADD y discordgo saca al
+// miembro del estado antes de llamar a los handlers, así que sin esta copia el
+// ban llega sin fecha de entrada ni apodo.
+type memberSnapshots struct {
+	mu        sync.Mutex
+	members   map[string]memberSnapshot
+	lastPrune time.Time
+}
+
+type memberSnapshot struct {
+	member discordgo.Member
+	seen   time.Time
+}
+
+func (c *memberSnapshots) remember(guildID string, member *discordgo.Member, user *discordgo.User) {
+	if member == nil || user == nil {
+		return
+	}
+	now := time.Now()
+	copied := *member
+	copied.GuildID, copied.User = guildID, user
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.members[guildID+":"+user.ID] = memberSnapshot{member: copied, seen: now}
+	if now.Sub(c.lastPrune) > time.Hour {
+		c.lastPrune = now
+		for key, snap := range c.members {
+			if now.Sub(snap.seen) > memberSnapshotTTL {
+				delete(c.members, key)
+			}
+		}
+	}
+}
+
+// touch extiende la copia del miembro al salir, para que siga disponible si lo
+// banean después.
+func (c *memberSnapshots) touch(guildID, userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if snap, ok := c.members[guildID+":"+userID]; ok {
+		snap.seen = time.Now()
+		c.members[guildID+":"+userID] = snap
+	}
+}
+
+func (c *memberSnapshots) recall(guildID, userID string) (*discordgo.Member, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snap, ok := c.members[guildID+":"+userID]
+	if !ok || time.Since(snap.seen) > memberSnapshotTTL {
+		return nil, false
+	}
+	member := snap.member
+	return &member, true
+}
+
+// BanStore persiste las huellas de los baneados por servidor.
+type BanStore struct {
+	mu   sync.Mutex
+	path string
+	bans map[string][]BanFingerprint
+}
+
+func NewBanStore(path string) *BanStore {
+	b := &BanStore{path: path, bans: make(map[string][]BanFingerprint)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error leyendo baneos en %s: %v\n", path, err)
+		}
+		return b
+	}
+	if err := json.Unmarshal(data, &b.bans); err != nil {
+		fmt.Printf("Error deserializando baneos: %v\n", err)
+	}
+	return b
+}
+
+// saveLocked debe llamarse con b.mu tomado.
+func (b *BanStore) saveLocked() {
+	data, err := json.MarshalIndent(b.bans, "", "  ")
+	if err != nil {
+		fmt.Printf("Error serializando baneos: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(b.path, data, 0644); err != nil {
+		fmt.Printf("Error guardando baneos en %s: %v\n", b.path, err)
+	}
+}
+
+func (b *BanStore) Add(guildID string, fp BanFingerprint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bans := slices.DeleteFunc(b.bans[guildID], func(old BanFingerprint) bool {
+		return old.UserID == fp.UserID
+	})
+	bans = append(bans, fp)
+	if len(bans) > maxBanFingerprints {
+		bans = bans[len(bans)-maxBanFingerprints:]
+	}
+	b.bans[guildID] = bans
+	b.saveLocked()
+}
+
+func (b *BanStore) Remove(guildID, userID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bans, ok := b.bans[guildID]
+	if !ok {
+		return
+	}
+	b.bans[guildID] = slices.DeleteFunc(bans, func(fp BanFingerprint) bool {
+		return fp.UserID == userID
+	})
+	b.saveLocked()
+}
+
+// Recent devuelve las huellas de los baneos de los últimos retention.
+func (b *BanStore) Recent(guildID string, retention time.Duration) []BanFingerprint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var recent []BanFingerprint
+	for _, fp := range b.bans[guildID] {
+		if time.Since(fp.BannedAt) < retention {
+			recent = append(recent, fp)
+		}
+	}
+	return recent
+}
+
+func (m *Manager) banEvasionConfig(guildID string) BanEvasionConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok && cfg.BanEvasion != nil {
+		return *cfg.BanEvasion
+	}
+	return DefaultBanEvasionConfig
+}
+
+func (m *Manager) SetBanEvasion(guildID string, enabled bool) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	if m.GuildConfig[guildID].BanEvasion == nil {
+		evasion := DefaultBanEvasionConfig
+		m.GuildConfig[guildID].BanEvasion = &evasion
+	}
+	m.GuildConfig[guildID].BanEvasion.Enabled = enabled
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+func displayName(member *discordgo.Member) string {
+	if member.Nick != "" {
+		return member.Nick
+	}
+	return member.User.GlobalName
+}
+
+// HandleBanAdd guarda la huella del usuario baneado.
+func (m *Manager) HandleBanAdd(s *discordgo.Session, ev *discordgo.GuildBanAdd) {
+	if ev.User == nil || ev.User.Bot {
+		return
+	}
+
+	member, err := s.State.Member(ev.GuildID, ev.User.ID)
+	if err != nil {
+		var ok bool
+		if member, ok = m.members.recall(ev.GuildID, ev.User.ID); !ok {
+			member = &discordgo.Member{GuildID: ev.GuildID, User: ev.User}
+		}
+	}
+	fp := BanFingerprint{
+		UserID:      ev.User.ID,
+		Username:    ev.User.Username,
+		NameBase:    nameBase(ev.User.Username),
+		DisplayBase: nameBase(displayName(member)),
+		JoinedAt:    member.JoinedAt,
+		BannedAt:    time.Now(),
+	}
+	if scan := m.scanAvatar(member); scan != nil {
+		fp.AvatarHash = scan.hash.GetHash()
+	}
+	if ban, err := s.GuildBan(ev.GuildID, ev.User.ID); err == nil {
+		fp.Reason = ban.Reason
+	}
+	m.Bans.Add(ev.GuildID, fp)
+}
+
+// HandleMemberLeave conserva la copia del miembro que sale por si lo banean.
+func (m *Manager) HandleMemberLeave(s *discordgo.Session, ev *discordgo.GuildMemberRemove) {
+	if ev.Member != nil && ev.User != nil {
+		m.members.touch(ev.GuildID, ev.User.ID)
+	}
+}
+
+// HandleBanRemove olvida al usuario si le levantan el ban.
+func (m *Manager) HandleBanRemove(s *discordgo.Session, ev *discordgo.GuildBanRemove) {
+	if ev.User != nil {
+		m.Bans.Remove(ev.GuildID, ev.User.ID)
+		// Un desbaneo manual deja sin sentido el del ban temporal, y si después
+		// lo banean para siempre no debe desbanearlo
+		m.Scheduler.CancelFor(ev.GuildID, JobUnban, ev.User.ID)
+	}
+}
+
+// banEvasionScore puntúa cuánto se parece una cuenta nueva a un baneado.
+func banEvasionScore(fp BanFingerprint, member *discordgo.Member, avatar *avatarScan, created, joined time.Time) (int, []string) {
+	score := 0
+	var reasons []string
+
+	if avatar != nil && fp.AvatarHash != 0 && sameAvatar(avatar.hash, goimagehash.NewImageHash(fp.AvatarHash, goimagehash.PHash)) {
+		score += 45
+		reasons = append(reasons, "mismo avatar")
+	}
+
+	base := nameBase(member.User.Username)
+	switch {
+	case len(base) >= 4 && base == fp.NameBase:
+		score += 35
+		reasons = append(reasons, "mismo nombre de usuario")
+	case similarNames(base, fp.NameBase):
+		score += 25
+		reasons = append(reasons, "nombre de usuario parecido")
+	}
+	if display := nameBase(displayName(member)); fp.DisplayBase != "" && similarNames(display, fp.DisplayBase) {
+		score += 20
+		reasons = append(reasons, "nombre visible parecido")
+	}
+
+	// Las señales genéricas solo refuerzan un parecido concreto: cualquier
+	// cuenta nueva que entra después de un ban las cumple
+	if score == 0 {
+		return 0, nil
+	}
+	if created.After(fp.BannedAt) {
+		score += 20
+		reasons = append(reasons, "cuenta creada después del ban")
+	}
+	if joined.Sub(fp.BannedAt) < 48*time.Hour {
+		score += 10
+		reasons = append(reasons, "entró menos de 48h después del ban")
+	}
+	return score, reasons
+}
+
+// checkBanEvasion compara al miembro que entra con los baneados recientes y
+// avisa con un botón para banearlo si se parece lo suficiente a alguno.
+func (m *Manager) checkBanEvasion(s *discordgo.Session, guildID string, member *discordgo.Member, avatar *avatarScan) {
+	cfg := m.banEvasionConfig(guildID)
+	if !cfg.Enabled || member == nil || member.User == nil {
+		return
+	}
+
+	created, _ := discordgo.SnowflakeTimestamp(member.User.ID)
+	joined := time.Now()
+
+	var best BanFingerprint
+	bestScore := 0
+	var bestReasons []string
+	for _, fp := range m.Bans.Recent(guildID, time.Duration(cfg.RetentionDays)*24*time.Hour) {
+		if fp.UserID == member.User.ID {
+			continue
+		}
+		score, reasons := banEvasionScore(fp, member, avatar, created, joined)
+		if score > bestScore {
+			best, bestScore, bestReasons = fp, score, reasons
+		}
+	}
+	if bestScore < cfg.Threshold {
+		return
+	}
+
+	channelID := m.GetEventsChannel(guildID)
+	if channelID == "" {
+		channelID = m.GetLogChannel(guildID)
+	}
+	if channelID == "" {
+		return
+	}
+
+	description := fmt.Sprintf("Usuario: <@%s> (%s)\nCuenta creada: <t:%d:R>\nPosible cuenta de: <@%s> (%s), baneado <t:%d:R>\nPuntaje: **%d**\nSeñales: %s",
+		member.User.ID, member.User.String(), created.Unix(), best.UserID, best.Username, best.BannedAt.Unix(), bestScore, strings.Join(bestReasons, ", "))
+	if !best.JoinedAt.IsZero() {
+		description += fmt.Sprintf("\nEl original estuvo %s en el servidor", best.BannedAt.Sub(best.JoinedAt).Round(time.Minute))
+	}
+	if best.Reason != "" {
+		description += "\nMotivo del ban original: " + best.Reason
+	}
+	_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       "🕵️ Posible Evasión de Ban",
+			Description: description,
+			Color:       0xe67e22,
+			Timestamp:   time.Now().Format(time.RFC3339),
+		}},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Banear", Style: discordgo.DangerButton, CustomID: BanEvasionButtonPrefix + member.User.ID},
+			}},
+		},
+	})
+	if err != nil {
+		fmt.Printf("Error enviando aviso de evasión de ban: %v\n", err)
+	}
+}
@@ -0,0 +1,274 @@
+package automod
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Solo se consideran miembros con al menos esta antigüedad en el servidor
+	compromisedMinTenure = 30 * 24 * time.Hour
+	compromisedFanOut    = time.Minute
+	compromisedPurge     = 10 * time.Minute
+	// Mensajes mínimos en el historial de horas para decidir qué es inusual
+	hourHistoryMin  = 50
+	hourHistorySave = time.Minute
+)
+
+// CompromisedAdvice es el mensaje privado que recibe el usuario restringido.
+const CompromisedAdvice = "Tu cuenta envió mensajes con links o imágenes en varios canales de golpe, algo típico de una cuenta robada, así que quedó restringida en el servidor.\n\n" +
+	"Si no fuiste tú:\n" +
+	"1. Cambia tu contraseña de Discord (esto cierra las demás sesiones).\n" +
+	"2. Activa la autenticación en dos pasos.\n" +
+	"3. Revisa las aplicaciones autorizadas en Ajustes > Aplicaciones autorizadas y quita las que no reconozcas.\n" +
+	"4. No escanees códigos QR ni ejecutes archivos que te pasen por mensaje.\n\n" +
+	"Cuando la cuenta esté segura, avisa a los moderadores para que levanten la restricción."
+
+type CompromisedConfig struct {
+	Enabled   bool `json:"enabled"`
+	Threshold int  `json:"threshold"`
+}
+
+var DefaultCompromisedConfig = CompromisedConfig{
+	Enabled:   false,
+	Threshold: 75,
+}
+
+// HourHistogram cuenta los mensajes de cada usuario por hora del día (UTC)
+// para saber a qué horas suele escribir.
+type HourHistogram struct {
+	mu       sync.Mutex
+	path     string
+	users    map[string]*[24]int
+	lastSave time.Time
+}
+
+func NewHourHistogram(path string) *HourHistogram {
+	h := &HourHistogram{path: path, users: make(map[string]*[24]int)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error leyendo horarios en %s: %v\n", path, err)
+		}
+		return h
+	}
+	if err := json.Unmarshal(data, &h.users); err != nil {
+		fmt.Printf("Error deserializando horarios: %v\n", err)
+	}
+	return h
+}
+
+// saveLocked debe llamarse con h.mu tomado.
+func (h *HourHistogram) saveLocked() {
+	data, err := json.Marshal(h.users)
+	if err != nil {
+		fmt.Printf("Error serializando horarios: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(h.path, data, 0644); err != nil {
+		fmt.Printf("Error guardando horarios en %s: %v\n", h.path, err)
+		return
+	}
+	h.lastSave = time.Now()
+}
+
+// Record suma un mensaje; el archivo se escribe como mucho una vez por hourHistorySave.
+func (h *HourHistogram) Record(userID string, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hours, ok := h.users[userID]
+	if !ok {
+		hours = &[24]int{}
+		h.users[userID] = hours
+	}
+	hours[at.UTC().Hour()]++
+	if time.Since(h.lastSave) >= hourHistorySave {
+		h.saveLocked()
+	}
+}
+
+// Unusual indica si el usuario casi nunca escribe a esa hora (ni en las
+// vecinas). Sin historial suficiente no se sabe, y devuelve false.
+func (h *HourHistogram) Unusual(userID string, at time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hours, ok := h.users[userID]
+	if !ok {
+		return false
+	}
+	total := 0
+	for _, n := range hours {
+		total += n
+	}
+	if total < hourHistoryMin {
+		return false
+	}
+	hour := at.UTC().Hour()
+	near := hours[(hour+23)%24] + hours[hour] + hours[(hour+1)%24]
+	return float64(near)/float64(total) < 0.03
+}
+
+type burst struct {
+	start   time.Time
+	silence time.Duration
+}
+
+// activityBursts recuerda cuánto llevaba callado el usuario al empezar una
+// ráfaga, porque a partir del segundo mensaje LastActivity ya es reciente.
+type activityBursts struct {
+	mu    sync.Mutex
+	users map[string]burst
+}
+
+// silence devuelve el silencio previo a la ráfaga en curso, o empieza una
+// nueva con el silencio actual.
+func (b *activityBursts) silence(userID string, current time.Duration) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if cur, ok := b.users[userID]; ok && time.Since(cur.start) < compromisedFanOut {
+		return cur.silence
+	}
+	if len(b.users) > 1000 {
+		for id, old := range b.users {
+			if time.Since(old.start) >= compromisedFanOut {
+				delete(b.users, id)
+			}
+		}
+	}
+	b.users[userID] = burst{start: time.Now(), silence: current}
+	return current
+}
+
+func (m *Manager) compromisedConfig(guildID string) CompromisedConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok && cfg.Compromised != nil {
+		return *cfg.Compromised
+	}
+	return DefaultCompromisedConfig
+}
+
+func (m *Manager) SetCompromisedDetection(guildID string, enabled bool) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	if m.GuildConfig[guildID].Compromised == nil {
+		compromised := DefaultCompromisedConfig
+		m.GuildConfig[guildID].Compromised = &compromised
+	}
+	m.GuildConfig[guildID].Compromised.Enabled = enabled
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+func hasLinks(content string) bool {
+	return len(ExtractLinks(content, nil)) > 0 || len(ExtractInviteCodes(content)) > 0
+}
+
+// compromisedScore combina las señales débiles de una cuenta robada: cuánto
+// llevaba sin escribir, en cuántos canales publicó links o imágenes en el
+// último minuto y si es una hora inusual para el usuario.
+func compromisedScore(silence time.Duration, channels int, unusualHour bool) (int, []string) {
+	score := 20
+	signals := []string{"link o imagen"}
+
+	switch {
+	case silence >= 14*24*time.Hour:
+		score += 35
+		signals = append(signals, fmt.Sprintf("%d días sin escribir", int(silence.Hours()/24)))
+	case silence >= 3*24*time.Hour:
+		score += 15
+		signals = append(signals, fmt.Sprintf("%d días sin escribir", int(silence.Hours()/24)))
+	}
+
+	switch {
+	case channels >= 3:
+		score += 35
+		signals = append(signals, fmt.Sprintf("%d canales en un minuto", channels))
+	case channels == 2:
+		score += 15
+		signals = append(signals, "2 canales en un minuto")
+	}
+
+	if unusualHour {
+		score += 15
+		signals = append(signals, "hora inusual")
+	}
+	return score, signals
+}
+
+// checkCompromised busca el patrón de cuenta robada en miembros antiguos y, si
+// lo encuentra, los pone en cuarentena, borra sus mensajes recientes y les
+// manda consejos por privado. Devuelve true si actuó.
+func (m *Manager) checkCompromised(s *discordgo.Session, msg *discordgo.MessageCreate) bool {
+	cfg := m.compromisedConfig(msg.GuildID)
+	if !cfg.Enabled || msg.Member == nil || time.Since(msg.Member.JoinedAt) < compromisedMinTenure {
+		return false
+	}
+	if len(msg.Attachments) == 0 && !hasLinks(msg.Content) {
+		return false
+	}
+	if m.isStaff(msg.GuildID, msg.Member) {
+		return false
+	}
+
+	m.mu.RLock()
+	lastSeen, seen := m.LastActivity[msg.Author.ID]
+	m.mu.RUnlock()
+	var silence time.Duration
+	if seen {
+		silence = time.Since(lastSeen)
+	}
+
+	channels := make(map[string]bool)
+	for _, cached := range m.Messages.UserMessages(msg.GuildID, msg.Author.ID, time.Now().Add(-compromisedFanOut)) {
+		if cached.Attachments > 0 || hasLinks(cached.Content) {
+			channels[cached.ChannelID] = true
+		}
+	}
+
+	silence = m.bursts.silence(msg.Author.ID, silence)
+
+	score, signals := compromisedScore(silence, len(channels), m.Hours.Unusual(msg.Author.ID, time.Now()))
+	if score < cfg.Threshold {
+		return false
+	}
+
+	s.ChannelMessageDelete(msg.ChannelID, msg.ID)
+	count, purged := m.PurgeRecentMessages(s, msg.GuildID, msg.Author.ID, compromisedPurge, msg.ID)
+
+	result := "En cuarentena hasta /release"
+	if err := m.QuarantineMember(s, msg.GuildID, msg.Author.ID, "Cuenta posiblemente comprometida", "Sentinel", 0); errors.Is(err, errNoQuarantineRole) {
+		// Sin rol de cuarentena no se aplica un timeout largo a ciegas: queda la alerta
+		result = "Sin sanción: no hay rol de cuarentena configurado (/set quarantine-role)"
+	} else if err != nil {
+		fmt.Printf("Error poniendo en cuarentena a %s: %v\n", msg.Author.ID, err)
+		until := time.Now().Add(maxTimeout)
+		if err := s.GuildMemberTimeout(msg.GuildID, msg.Author.ID, &until); err != nil {
+			fmt.Printf("Error muteando usuario %s: %v\n", msg.Author.ID, err)
+		}
+		result = fmt.Sprintf("Aislado hasta <t:%d:f> (sin cuarentena: %v)", until.Unix(), err)
+	}
+
+	dm := "No se pudo enviar el aviso por privado"
+	if ch, err := s.UserChannelCreate(msg.Author.ID); err == nil {
+		if _, err := s.ChannelMessageSend(ch.ID, CompromisedAdvice); err == nil {
+			dm = "Aviso enviado por privado"
+		}
+	}
+
+	detail := fmt.Sprintf("Puntaje: **%d**\nSeñales: %s\nAcción: %s\n%s", score, strings.Join(signals, ", "), result, dm)
+	if count > 0 {
+		detail += "\n" + formatPurge(count, purged)
+	}
+	m.LogSanction(s, msg.GuildID, msg.Author, "Cuenta Comprometida", detail, nil)
+	return true
+}
@@ -0,0 +1,285 @@
+package automod
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// LookalikeThreshold es el puntaje a partir del cual un dominio se considera suplantación.
+	LookalikeThreshold = 0.8
+	// Largo mínimo de la marca para comparar por distancia de edición: con
+	// nombres cortos (steam, nitro) una letra de diferencia es otra palabra
+	// (stream, astro).
+	lookalikeMinName   = 6
+	domainReloadPeriod = 30 * time.Second
+)
+
+// DomainList es una lista de dominios en formato hosts ("0.0.0.0 dominio") o
+// plano (un dominio por línea) que se recarga sola si el archivo cambia.
+// Con otra normalización sirve también para listas de hashes.
+type DomainList struct {
+	path      string
+	normalize func(string) string
+	mu        sync.RWMutex
+	domains   map[string]bool
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+func NewDomainList(path string) *DomainList {
+	return newList(path, NormalizeHost)
+}
+
+func newList(path string, normalize func(string) string) *DomainList {
+	l := &DomainList{path: path, normalize: normalize, domains: make(map[string]bool)}
+	l.reload()
+	return l
+}
+
+func (l *DomainList) reload() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lastCheck = time.Now()
+	info, err := os.Stat(l.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error leyendo lista de dominios %s: %v\n", l.path, err)
+		}
+		return
+	}
+	if !info.ModTime().After(l.modTime) {
+		return
+	}
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		fmt.Printf("Error abriendo lista de dominios %s: %v\n", l.path, err)
+		return
+	}
+	defer file.Close()
+
+	domains := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		domain := fields[0]
+		if len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+			domain = fields[1]
+		}
+		if host := l.normalize(domain); host != "" {
+			domains[host] = true
+		}
+	}
+
+	l.domains = domains
+	l.modTime = info.ModTime()
+	fmt.Printf("Cargadas %d entradas de %s\n", len(domains), filepath.Base(l.path))
+}
+
+// Match busca el host o cualquiera de sus dominios padre en la lista.
+func (l *DomainList) Match(host string) (string, bool) {
+	l.mu.RLock()
+	stale := time.Since(l.lastCheck) > domainReloadPeriod
+	l.mu.RUnlock()
+	if stale {
+		l.reload()
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for h := host; h != ""; {
+		if l.domains[h] {
+			return h, true
+		}
+		i := strings.IndexByte(h, '.')
+		if i < 0 {
+			break
+		}
+		h = h[i+1:]
+	}
+	return "", false
+}
+
+// NormalizeHost pasa el host a minúsculas y a ASCII (punycode para IDN).
+func NormalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if host == "" {
+		return ""
+	}
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		// Hosts con caracteres inválidos para IDNA igual se comparan tal cual
+		return host
+	}
+	return ascii
+}
+
+type DomainVerdict struct {
+	Host      string
+	Blocked   bool
+	Allowed   bool
+	Score     float64
+	Brand     string
+	Reason    string
+	ListMatch string
+}
+
+type DomainReputation struct {
+	Allow *DomainList
+	Block *DomainList
+}
+
+func NewDomainReputation(dir string) *DomainReputation {
+	return &DomainReputation{
+		Allow: NewDomainList(filepath.Join(dir, "allowlist.txt")),
+		Block: NewDomainList(filepath.Join(dir, "blocklist.txt")),
+	}
+}
+
+// Check clasifica un host: lista blanca, lista negra, dominio oficial de una
+// marca o, si no, puntaje de parecido con las marcas protegidas.
+func (d *DomainReputation) Check(rawHost string) DomainVerdict {
+	host := NormalizeHost(rawHost)
+	v := DomainVerdict{Host: host}
+	if host == "" {
+		return v
+	}
+
+	if match, ok := d.Allow.Match(host); ok {
+		v.Allowed, v.ListMatch = true, match
+		return v
+	}
+	if match, ok := d.Block.Match(host); ok {
+		v.Blocked, v.ListMatch = true, match
+		v.Reason = fmt.Sprintf("En la lista negra (`%s`)", match)
+		return v
+	}
+	if brandOf(host) != "" {
+		v.Allowed = true
+		return v
+	}
+
+	v.Score, v.Brand = lookalikeScore(host)
+	if v.Score > LookalikeThreshold {
+		v.Blocked = true
+		v.Reason = fmt.Sprintf("Se parece a %s (puntaje %.2f)", v.Brand, v.Score)
+	}
+	return v
+}
+
+// brandNames son los nombres contra los que se compara: las marcas y las
+// etiquetas de sus dominios oficiales (steamcommunity, discordapp...).
+func brandNames() map[string]string {
+	names := make(map[string]string)
+	for _, d := range slices.Concat(slices.Collect(maps.Values(ProtectedBrands))...) {
+		label := strings.SplitN(d, ".", 2)[0]
+		names[label] = brandOf(d)
+	}
+	for brand := range ProtectedBrands {
+		names[brand] = brand
+	}
+	return names
+}
+
+// lookalikeScore compara la etiqueta registrable del host con las marcas
+// protegidas. Puntúa alto solo dos casos: homógrafos (la marca aparece recién
+// al plegar los confusables que usa la etiqueta) y nombres a una edición de
+// una marca larga. Contener la marca tal cual (steamdb, discord-player) no
+// cuenta.
+func lookalikeScore(host string) (float64, string) {
+	unicodeHost, err := idna.Lookup.ToUnicode(host)
+	if err != nil {
+		unicodeHost = host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(unicodeHost)
+	if err != nil {
+		domain = unicodeHost
+	}
+	label := strings.SplitN(domain, ".", 2)[0]
+	lower := strings.ToLower(label)
+
+	// Los pliegues de varias letras (rn -> m) solo se aplican si la etiqueta
+	// ya usa confusables; sobre ASCII común convierten palabras normales en marcas
+	skeleton := Skeleton(label, DefaultLeetMap)
+	disguised := skeleton != lower || strings.IndexFunc(label, func(r rune) bool { return r > unicode.MaxASCII }) >= 0
+	folded := lower
+	if disguised {
+		folded = foldLookalike(skeleton)
+	}
+
+	tokens := strings.FieldsFunc(folded, func(r rune) bool { return r == '-' || r == '_' })
+	tokens = append(tokens, strings.ReplaceAll(folded, "-", ""))
+
+	var best float64
+	var bestBrand string
+	for name, brand := range brandNames() {
+		for _, tok := range tokens {
+			var score float64
+			switch {
+			case disguised && strings.Contains(tok, name) && !strings.Contains(lower, name):
+				score = 1
+			case len([]rune(name)) >= lookalikeMinName && levenshtein(tok, name) == 1:
+				score = 1 - 1/float64(max(len([]rune(tok)), len([]rune(name))))
+			}
+			if score > best {
+				best, bestBrand = score, brand
+			}
+		}
+	}
+	return best, bestBrand
+}
+
+// foldLookalike aplica sustituciones de varios caracteres que no cubre el
+// esqueleto (rn -> m, vv -> w, cl -> d).
+func foldLookalike(s string) string {
+	return strings.NewReplacer("rn", "m", "vv", "w", "cl", "d").Replace(s)
+}
+
+func tld(host string) string {
+	if i := strings.LastIndexByte(host, '.'); i >= 0 {
+		return host[i+1:]
+	}
+	return host
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
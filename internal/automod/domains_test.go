@@ -0,0 +1,38 @@
+package automod
+
+import "testing"
+
+func TestDomainCheck(t *testing.T) {
+	d := NewDomainReputation(t.TempDir())
+	tests := []struct {
+		host    string
+		blocked bool
+	}{
+		{"discord.com", false},
+		{"steamcommunity.com", false},
+		{"stream.com", false},
+		{"astro.build", false},
+		{"metro.net", false},
+		{"intro.co", false},
+		{"micro.blog", false},
+		{"retro.xyz", false},
+		{"discourse.org", false},
+		{"steamdb.info", false},
+		{"steamgifts.com", false},
+		{"steamdeck.com", false},
+		{"discord-player.js.org", false},
+		{"nitro.build", false},
+		{"modern.com", false},
+		{"clean.io", false},
+		{"disc0rd.gg", true},
+		{"dlscord-gift.xyz", true},
+		{"robiox.com", true},
+		{"steamcommunlty.com", true},
+		{"xn--dscord-pvf.com", true}, // dіscord con i cirílica
+	}
+	for _, tt := range tests {
+		if v := d.Check(tt.host); v.Blocked != tt.blocked {
+			t.Errorf("Check(%q).Blocked = %v, se esperaba %v (puntaje %.2f, %s)", tt.host, v.Blocked, tt.blocked, v.Score, v.Brand)
+		}
+	}
+}
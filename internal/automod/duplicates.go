@@ -0,0 +1,301 @@
+package automod
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Qué hacer con un mensaje repetido
+const (
+	DuplicateAlert  = "alert"
+	DuplicateDelete = "delete"
+	// Borra las copias y aísla al autor 24h (solo si es una única cuenta)
+	DuplicateMute = "mute"
+)
+
+type DuplicateConfig struct {
+	Enabled bool `json:"enabled"`
+	// Mismo contenido de un usuario en esta cantidad de canales distintos
+	Channels int `json:"channels"`
+	// Mismo contenido desde esta cantidad de cuentas distintas
+	Users int `json:"users"`
+	// Vacío equivale a DuplicateAlert: saludos en masa ("feliz año nuevo")
+	// son legítimos, así que por defecto solo se avisa
+	UsersAction string `json:"users_action"`
+	// Acción cuando una sola cuenta repite el mensaje en varios canales;
+	// vacío equivale a DuplicateAlert
+	Action        string `json:"action"`
+	WindowSeconds int    `json:"window_seconds"`
+	// Distancia de Hamming máxima entre SimHash para considerar casi duplicado
+	MaxDistance int  `json:"max_distance"`
+	MinLength   int  `json:"min_length"`
+	Purge       bool `json:"purge"`
+}
+
+var DefaultDuplicateConfig = DuplicateConfig{
+	Enabled:       true,
+	Channels:      3,
+	Users:         4,
+	UsersAction:   DuplicateAlert,
+	Action:        DuplicateAlert,
+	WindowSeconds: 60,
+	MaxDistance:   8,
+	MinLength:     15,
+	Purge:         true,
+}
+
+type dupEntry struct {
+	exact     uint64
+	sim       uint64
+	userID    string
+	channelID string
+	messageID string
+	at        time.Time
+}
+
+// DuplicateDetector guarda huellas de los mensajes recientes por servidor.
+type DuplicateDetector struct {
+	mu      sync.Mutex
+	entries map[string][]dupEntry
+}
+
+func NewDuplicateDetector() *DuplicateDetector {
+	return &DuplicateDetector{entries: make(map[string][]dupEntry)}
+}
+
+// alnumOnly deja solo letras y dígitos del texto ya normalizado.
+func alnumOnly(skeleton string) string {
+	var b strings.Builder
+	for _, r := range skeleton {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// fingerprint devuelve un hash exacto y un SimHash (shingles de 3 caracteres)
+// del texto compactado.
+func fingerprint(compact string) (uint64, uint64) {
+	runes := []rune(compact)
+
+	h := fnv.New64a()
+	h.Write([]byte(string(runes)))
+	exact := h.Sum64()
+
+	var weights [64]int
+	for i := 0; i+3 <= len(runes); i++ {
+		sh := fnv.New64a()
+		sh.Write([]byte(string(runes[i : i+3])))
+		v := sh.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if v&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var sim uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			sim |= 1 << bit
+		}
+	}
+	return exact, sim
+}
+
+// Check registra el mensaje y devuelve las copias (incluida la actual) si se
+// superó alguno de los umbrales, junto con la descripción del motivo.
+func (d *DuplicateDetector) Check(guildID string, entry dupEntry, cfg DuplicateConfig) ([]dupEntry, string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	window := time.Duration(cfg.WindowSeconds) * time.Second
+	var kept []dupEntry
+	for _, e := range d.entries[guildID] {
+		if entry.at.Sub(e.at) < window {
+			kept = append(kept, e)
+		}
+	}
+	kept = append(kept, entry)
+	d.entries[guildID] = kept
+
+	var copies []dupEntry
+	for _, e := range kept {
+		if e.exact == entry.exact || bits.OnesCount64(e.sim^entry.sim) <= cfg.MaxDistance {
+			copies = append(copies, e)
+		}
+	}
+
+	channels := make(map[string]bool)
+	users := make(map[string]bool)
+	for _, e := range copies {
+		users[e.userID] = true
+		if e.userID == entry.userID {
+			channels[e.channelID] = true
+		}
+	}
+
+	switch {
+	case cfg.Users > 0 && len(users) >= cfg.Users:
+		d.removeLocked(guildID, copies)
+		return copies, fmt.Sprintf("Mismo mensaje enviado por %d cuentas en menos de %s.", len(users), window)
+	case cfg.Channels > 0 && len(channels) >= cfg.Channels:
+		var own []dupEntry
+		for _, e := range copies {
+			if e.userID == entry.userID {
+				own = append(own, e)
+			}
+		}
+		d.removeLocked(guildID, own)
+		return own, fmt.Sprintf("Mismo mensaje enviado en %d canales en menos de %s.", len(channels), window)
+	}
+	return nil, ""
+}
+
+// removeLocked saca las copias ya sancionadas para que no vuelvan a disparar.
+func (d *DuplicateDetector) removeLocked(guildID string, copies []dupEntry) {
+	drop := make(map[string]bool, len(copies))
+	for _, c := range copies {
+		drop[c.messageID] = true
+	}
+	var kept []dupEntry
+	for _, e := range d.entries[guildID] {
+		if !drop[e.messageID] {
+			kept = append(kept, e)
+		}
+	}
+	d.entries[guildID] = kept
+}
+
+func (m *Manager) duplicateConfig(guildID string) DuplicateConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok && cfg.Duplicates != nil {
+		return *cfg.Duplicates
+	}
+	return DefaultDuplicateConfig
+}
+
+func (m *Manager) SetDuplicateDetection(guildID string, enabled bool) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	if m.GuildConfig[guildID].Duplicates == nil {
+		dup := DefaultDuplicateConfig
+		m.GuildConfig[guildID].Duplicates = &dup
+	}
+	m.GuildConfig[guildID].Duplicates.Enabled = enabled
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+// checkDuplicates detecta el mismo contenido repetido entre canales o
+// cuentas y, si está configurado, borra todas las copias salvo la actual.
+func (m *Manager) checkDuplicates(s *discordgo.Session, msg *discordgo.MessageCreate) *Detection {
+	cfg := m.duplicateConfig(msg.GuildID)
+	if !cfg.Enabled {
+		return nil
+	}
+	// Emojis y signos no dejan nada que comparar y chocarían todos entre sí
+	compact := alnumOnly(m.NormalizeContent(msg.GuildID, msg.Content))
+	if compact == "" || len([]rune(compact)) < cfg.MinLength {
+		return nil
+	}
+
+	exact, sim := fingerprint(compact)
+	copies, why := m.Duplicates.Check(msg.GuildID, dupEntry{
+		exact:     exact,
+		sim:       sim,
+		userID:    msg.Author.ID,
+		channelID: msg.ChannelID,
+		messageID: msg.ID,
+		at:        time.Now(),
+	}, cfg)
+	if copies == nil {
+		return nil
+	}
+
+	users := make(map[string]bool)
+	channels := make(map[string]bool)
+	byChannel := make(map[string][]string)
+	for _, c := range copies {
+		users[c.userID] = true
+		channels[c.channelID] = true
+		if c.messageID != msg.ID {
+			byChannel[c.channelID] = append(byChannel[c.channelID], c.messageID)
+		}
+	}
+
+	detail := fmt.Sprintf("%s\nCopias: %d\nCanales: %s\nCuentas: %s\nContenido: `%s`",
+		why, len(copies), joinMentions(channels, "<#%s>"), joinMentions(users, "<@%s>"), truncate(msg.Content, 300))
+
+	action := cfg.Action
+	if len(users) > 1 {
+		// Varias cuentas: no se sabe cuál es la original, nunca se aísla
+		action = cfg.UsersAction
+		if action == DuplicateMute {
+			action = DuplicateDelete
+		}
+	}
+	if action != DuplicateDelete && action != DuplicateMute {
+		m.LogSanction(s, msg.GuildID, msg.Author, "Mensaje Duplicado", detail+"\nAcción: Solo aviso", nil)
+		return nil
+	}
+
+	if cfg.Purge {
+		for channelID, ids := range byChannel {
+			m.deleteMessages(s, channelID, ids)
+		}
+		detail += "\nCopias borradas: sí"
+	}
+
+	mute := time.Duration(0)
+	if action == DuplicateMute {
+		mute = 24 * time.Hour
+	}
+	return &Detection{Reason: "Mensaje Duplicado", Detail: detail, Mute: mute}
+}
+
+func joinMentions(ids map[string]bool, format string) string {
+	var out []string
+	for id := range ids {
+		out = append(out, fmt.Sprintf(format, id))
+	}
+	return strings.Join(out, ", ")
+}
+
+// deleteMessages borra mensajes de un canal, en bloque si son varios, y
+// devuelve cuántos se borraron.
+func (m *Manager) deleteMessages(s *discordgo.Session, channelID string, ids []string) int {
+	deleted := 0
+	for len(ids) > 0 {
+		n := min(len(ids), 100)
+		var err error
+		if n == 1 {
+			err = s.ChannelMessageDelete(channelID, ids[0])
+		} else {
+			err = s.ChannelMessagesBulkDelete(channelID, ids[:n])
+		}
+		if err != nil {
+			fmt.Printf("Error borrando mensajes en %s: %v\n", channelID, err)
+		} else {
+			deleted += n
+		}
+		for _, id := range ids[:n] {
+			m.Messages.Remove(id)
+		}
+		ids = ids[n:]
+	}
+	return deleted
+}
@@ -0,0 +1,130 @@
+package automod
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Acciones para quien escribe en el canal trampa
+const (
+	HoneypotActionBan        = "ban"
+	HoneypotActionKick       = "kick"
+	HoneypotActionTimeout    = "timeout"
+	HoneypotActionQuarantine = "quarantine"
+)
+
+const honeypotTimeout = 24 * time.Hour
+
+// HoneypotNotice es el aviso que se publica en el canal al configurarlo.
+const HoneypotNotice = "⚠️ **No escribas en este canal.** Cualquier mensaje que se envíe aquí se toma como spam y se sanciona automáticamente."
+
+type HoneypotConfig struct {
+	ChannelID string `json:"channel_id"`
+	Action    string `json:"action"`
+}
+
+func (m *Manager) honeypotConfig(guildID string) HoneypotConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok && cfg.Honeypot != nil {
+		return *cfg.Honeypot
+	}
+	return HoneypotConfig{}
+}
+
+func (m *Manager) GetHoneypotChannel(guildID string) string {
+	return m.honeypotConfig(guildID).ChannelID
+}
+
+// SetHoneypot configura el canal trampa; un canal vacío lo desactiva y una
+// acción vacía mantiene la anterior (ban si no había).
+func (m *Manager) SetHoneypot(guildID, channelID, action string) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	if m.GuildConfig[guildID].Honeypot == nil {
+		m.GuildConfig[guildID].Honeypot = &HoneypotConfig{Action: HoneypotActionBan}
+	}
+	m.GuildConfig[guildID].Honeypot.ChannelID = channelID
+	if action != "" {
+		m.GuildConfig[guildID].Honeypot.Action = action
+	}
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+// checkHoneypot sanciona a quien escribe en el canal trampa, sin pasar por
+// el resto de los filtros. Devuelve true si el mensaje era del canal trampa.
+func (m *Manager) checkHoneypot(s *discordgo.Session, msg *discordgo.MessageCreate) bool {
+	cfg := m.honeypotConfig(msg.GuildID)
+	if cfg.ChannelID == "" || msg.ChannelID != cfg.ChannelID {
+		return false
+	}
+	// Los mensajes de sistema (bienvenidas, boosts) llevan como autor al
+	// miembro aunque no haya escrito nada
+	if msg.Type != discordgo.MessageTypeDefault && msg.Type != discordgo.MessageTypeReply {
+		return true
+	}
+	if m.isStaff(msg.GuildID, msg.Member) {
+		return true
+	}
+	// Sin permisos conocidos no se sanciona: podría ser un moderador
+	perms, err := s.State.MessagePermissions(msg.Message)
+	if err != nil {
+		fmt.Printf("Error calculando permisos de %s en el canal trampa: %v\n", msg.Author.ID, err)
+		return true
+	}
+	if perms&(discordgo.PermissionManageMessages|discordgo.PermissionAdministrator) != 0 {
+		return true
+	}
+
+	var result string
+	switch cfg.Action {
+	case HoneypotActionKick:
+		s.ChannelMessageDelete(msg.ChannelID, msg.ID)
+		err = s.GuildMemberDeleteWithReason(msg.GuildID, msg.Author.ID, "Escribió en el canal trampa")
+		result = "Expulsado"
+	case HoneypotActionTimeout:
+		s.ChannelMessageDelete(msg.ChannelID, msg.ID)
+		until := time.Now().Add(honeypotTimeout)
+		err = s.GuildMemberTimeout(msg.GuildID, msg.Author.ID, &until)
+		result = fmt.Sprintf("Aislado hasta <t:%d:f>", until.Unix())
+	case HoneypotActionQuarantine:
+		s.ChannelMessageDelete(msg.ChannelID, msg.ID)
+		err = m.QuarantineMember(s, msg.GuildID, msg.Author.ID, "Escribió en el canal trampa", "Sentinel", 0)
+		result = "En cuarentena hasta /release"
+	default:
+		// El ban ya borra los mensajes del último día, incluido este
+		err = s.GuildBanCreateWithReason(msg.GuildID, msg.Author.ID, "Escribió en el canal trampa", 1)
+		result = "Baneado, mensajes del último día borrados"
+	}
+	if err != nil {
+		fmt.Printf("Error aplicando el canal trampa a %s: %v\n", msg.Author.ID, err)
+		result += fmt.Sprintf(" (falló: %v)", err)
+		s.ChannelMessageDelete(msg.ChannelID, msg.ID)
+	}
+
+	logChannel := m.GetLogChannel(msg.GuildID)
+	if logChannel == "" {
+		return true
+	}
+	content := msg.Content
+	if content == "" && len(msg.Attachments) > 0 {
+		content = fmt.Sprintf("(%d adjuntos)", len(msg.Attachments))
+	}
+	s.ChannelMessageSendEmbed(logChannel, &discordgo.MessageEmbed{
+		Title: "🍯 Canal Trampa",
+		Description: fmt.Sprintf("Usuario: <@%s> (%s)\nCanal: <#%s>\nCuenta creada: <t:%d:R>\nAcción: %s\nMensaje:\n```%s```",
+			msg.Author.ID, msg.Author.String(), msg.ChannelID, time.Now().Add(-accountAge(msg.Author.ID)).Unix(), result, truncate(strings.ReplaceAll(content, "`", "'"), 1000)),
+		Color:     0xff0000,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Sentinel Automod",
+		},
+	})
+	return true
+}
@@ -0,0 +1,167 @@
+package automod
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dlclark/regexp2"
+)
+
+const (
+	inviteCacheTTL    = time.Hour
+	inviteNegativeTTL = 10 * time.Minute
+)
+
+var (
+	errInvalidInvite  = errors.New("invitación inválida o vencida")
+	errNotGuildInvite = errors.New("la invitación no es de un servidor")
+)
+
+var inviteRe = compileFilter(`(?:https?://)?(?:www\.)?(?:discord(?:app)?\.com/invite|discord\.gg)/([\w-]+)`, regexp2.IgnoreCase)
+
+type resolvedInvite struct {
+	guildID   string
+	guildName string
+	err       error
+	at        time.Time
+}
+
+// InviteResolver resuelve códigos de invitación a su servidor, con caché.
+type InviteResolver struct {
+	mu    sync.Mutex
+	cache map[string]resolvedInvite
+}
+
+func NewInviteResolver() *InviteResolver {
+	return &InviteResolver{cache: make(map[string]resolvedInvite)}
+}
+
+// ExtractInviteCodes devuelve los códigos de discord.gg, discord.com/invite y
+// discordapp.com/invite del texto, sin repetir.
+func ExtractInviteCodes(content string) []string {
+	var codes []string
+	match, _ := inviteRe.FindStringMatch(content)
+	for match != nil {
+		code := match.Groups()[1].String()
+		if !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+		match, _ = inviteRe.FindNextMatch(match)
+	}
+	return codes
+}
+
+func (r *InviteResolver) Resolve(s *discordgo.Session, code string) (string, string, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[code]
+	r.mu.Unlock()
+
+	ttl := inviteCacheTTL
+	if cached.err != nil {
+		ttl = inviteNegativeTTL
+	}
+	if ok && time.Since(cached.at) < ttl {
+		return cached.guildID, cached.guildName, cached.err
+	}
+
+	res := resolvedInvite{at: time.Now()}
+	invite, err := s.Invite(code)
+	switch {
+	case isNotFound(err):
+		res.err = errInvalidInvite
+	case err != nil:
+		// Fallas de red o rate limit: no se guardan en caché
+		return "", "", err
+	case invite.Guild == nil:
+		res.err = errNotGuildInvite
+	default:
+		res.guildID, res.guildName = invite.Guild.ID, invite.Guild.Name
+	}
+
+	r.mu.Lock()
+	for key, c := range r.cache {
+		if time.Since(c.at) > inviteCacheTTL {
+			delete(r.cache, key)
+		}
+	}
+	r.cache[code] = res
+	r.mu.Unlock()
+
+	return res.guildID, res.guildName, res.err
+}
+
+// isNotFound indica si la API respondió 404 (invitación, canal o recurso inexistente).
+func isNotFound(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
+
+func (m *Manager) AllowInviteGuild(guildID, targetID string) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	cfg := m.GuildConfig[guildID]
+	if !slices.Contains(cfg.AllowedInviteGuilds, targetID) {
+		cfg.AllowedInviteGuilds = append(cfg.AllowedInviteGuilds, targetID)
+	}
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+func (m *Manager) DisallowInviteGuild(guildID, targetID string) {
+	m.mu.Lock()
+	if cfg, ok := m.GuildConfig[guildID]; ok {
+		cfg.AllowedInviteGuilds = slices.DeleteFunc(cfg.AllowedInviteGuilds, func(id string) bool { return id == targetID })
+	}
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+func (m *Manager) GetAllowedInviteGuilds(guildID string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok {
+		return slices.Clone(cfg.AllowedInviteGuilds)
+	}
+	return nil
+}
+
+// checkInvites resuelve cada invitación y la permite solo si apunta al propio
+// servidor o a uno de la lista permitida. Las inválidas o vencidas también se
+// borran; si la API falla, la invitación se deja pasar.
+func (m *Manager) checkInvites(s *discordgo.Session, guildID, content string) *Detection {
+	codes := ExtractInviteCodes(content)
+	if len(codes) == 0 {
+		return nil
+	}
+
+	allowed := m.GetAllowedInviteGuilds(guildID)
+	for _, code := range codes {
+		targetID, name, err := m.Invites.Resolve(s, code)
+		if err != nil && !errors.Is(err, errInvalidInvite) && !errors.Is(err, errNotGuildInvite) {
+			fmt.Printf("No se pudo resolver la invitación %s: %v\n", code, err)
+			continue
+		}
+		if err != nil {
+			return &Detection{
+				Reason: "Invitación",
+				Detail: fmt.Sprintf("Invitación no permitida.\nCódigo: `%s`\nMotivo: %v", code, err),
+			}
+		}
+		if targetID == guildID || slices.Contains(allowed, targetID) {
+			continue
+		}
+		return &Detection{
+			Reason: "Invitación",
+			Detail: fmt.Sprintf("Invitación a otro servidor.\nCódigo: `%s`\nServidor: **%s** (`%s`)", code, strings.ReplaceAll(name, "`", ""), targetID),
+		}
+	}
+	return nil
+}
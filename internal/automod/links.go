@@ -0,0 +1,213 @@
+package automod
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dlclark/regexp2"
+	"golang.org/x/net/publicsuffix"
+)
+
+type Link struct {
+	// Texto visible, solo para links enmascarados [texto](url)
+	Text   string
+	URL    string
+	Host   string
+	Masked bool
+}
+
+var (
+	markdownLinkRe = compileFilter(`\[([^\[\]]*)\]\(\s*<?(https?://[^\s<>()]+)>?(?:\s+"[^"]*")?\s*\)`, regexp2.IgnoreCase)
+	autoLinkRe     = compileFilter(`<(https?://[^\s<>]+)>`, regexp2.IgnoreCase)
+	bareLinkRe     = compileFilter(`https?://[^\s<>()\[\]"'`+"`"+`]+`, regexp2.IgnoreCase)
+	visibleHostRe  = compileFilter(`(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,}`, regexp2.IgnoreCase)
+)
+
+// ProtectedBrands son las marcas que suplantan los scams, con sus dominios oficiales.
+var ProtectedBrands = map[string][]string{
+	"discord": {"discord.com", "discord.gg", "discordapp.com", "discordapp.net", "discord.gift", "discord.media", "discordstatus.com"},
+	"nitro":   {"discord.com", "discord.gift"},
+	"steam":   {"steampowered.com", "steamcommunity.com", "steamstatic.com"},
+	"roblox":  {"roblox.com", "rbxcdn.com"},
+}
+
+// ExtractLinks devuelve los links de markdown [texto](url), autolinks <url>,
+// URLs sueltas y las URLs de los embeds, sin repetir.
+func ExtractLinks(content string, embeds []*discordgo.MessageEmbed) []Link {
+	var links []Link
+	seen := make(map[string]bool)
+	add := func(l Link) {
+		key := l.Text + "\x00" + l.URL
+		if l.Host == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		links = append(links, l)
+	}
+
+	rest := content
+	match, _ := markdownLinkRe.FindStringMatch(content)
+	for match != nil {
+		groups := match.Groups()
+		target := groups[2].String()
+		add(Link{Text: groups[1].String(), URL: target, Host: LinkHost(target), Masked: true})
+		rest = strings.Replace(rest, match.String(), " ", 1)
+		match, _ = markdownLinkRe.FindNextMatch(match)
+	}
+
+	match, _ = autoLinkRe.FindStringMatch(rest)
+	for match != nil {
+		target := match.Groups()[1].String()
+		add(Link{URL: target, Host: LinkHost(target)})
+		rest = strings.Replace(rest, match.String(), " ", 1)
+		match, _ = autoLinkRe.FindNextMatch(match)
+	}
+
+	match, _ = bareLinkRe.FindStringMatch(rest)
+	for match != nil {
+		target := strings.TrimRight(match.String(), ".,;:!?*_~|")
+		add(Link{URL: target, Host: LinkHost(target)})
+		match, _ = bareLinkRe.FindNextMatch(match)
+	}
+
+	for _, e := range embeds {
+		if e.URL != "" {
+			add(Link{URL: e.URL, Host: LinkHost(e.URL)})
+		}
+	}
+
+	return links
+}
+
+// LinkHost devuelve el hostname en minúsculas, sin puerto ni punto final.
+func LinkHost(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
+
+// RegistrableDomain devuelve el dominio registrable (eTLD+1), ej:
+// cdn.discordapp.com -> discordapp.com.
+func RegistrableDomain(host string) string {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// brandOf devuelve la marca protegida a la que pertenece el dominio
+// oficialmente. Recorre las marcas en orden para que un dominio compartido
+// (discord.com para discord y nitro) siempre dé la misma.
+func brandOf(host string) string {
+	domain := RegistrableDomain(host)
+	brands := slices.Sorted(maps.Keys(ProtectedBrands))
+	for _, brand := range brands {
+		if slices.Contains(ProtectedBrands[brand], domain) {
+			return brand
+		}
+	}
+	return ""
+}
+
+// sourceFileExts son extensiones de archivo que también son TLDs (main.go,
+// README.md, setup.py); en texto visible casi siempre nombran un archivo.
+var sourceFileExts = map[string]bool{
+	"c": true, "cc": true, "cpp": true, "cs": true, "css": true, "go": true, "h": true, "html": true,
+	"java": true, "js": true, "json": true, "kt": true, "lua": true, "md": true, "mod": true, "mov": true,
+	"php": true, "pl": true, "ps": true, "py": true, "rb": true, "rs": true, "sh": true, "so": true,
+	"sql": true, "sum": true, "swift": true, "toml": true, "ts": true, "txt": true, "xml": true,
+	"yaml": true, "yml": true, "zip": true,
+}
+
+// looksLikeHost indica si el texto es un dominio con sufijo público real y no
+// un nombre de archivo.
+func looksLikeHost(host string) bool {
+	suffix, icann := publicsuffix.PublicSuffix(host)
+	return icann && suffix != host && !sourceFileExts[tld(host)]
+}
+
+func sameSite(a, b string) bool {
+	if RegistrableDomain(a) == RegistrableDomain(b) {
+		return true
+	}
+	brand := brandOf(a)
+	return brand != "" && brand == brandOf(b)
+}
+
+// checkMaskedLink marca links cuyo texto visible nombra un dominio distinto
+// al destino real, o una marca protegida que no coincide con el destino.
+func checkMaskedLink(link Link) (string, bool, bool) {
+	if !link.Masked {
+		return "", false, false
+	}
+
+	visible := Skeleton(link.Text, nil)
+	match, _ := visibleHostRe.FindStringMatch(visible)
+	for match != nil {
+		shown := strings.ToLower(match.String())
+		if looksLikeHost(shown) && !sameSite(shown, link.Host) {
+			protected := brandOf(shown) != "" || brandOf(link.Host) != ""
+			return fmt.Sprintf("Texto visible: `%s` (%s)\nDestino real: `%s`", truncate(link.Text, 100), shown, link.URL), protected, true
+		}
+		match, _ = visibleHostRe.FindNextMatch(match)
+	}
+
+	// Sin dominio visible: solo cuenta si nombra una marca junto a un gancho de regalo
+	if brandOf(link.Host) != "" || !containsAny(visible, giftWords) {
+		return "", false, false
+	}
+	for brand := range ProtectedBrands {
+		if strings.Contains(visible, brand) {
+			return fmt.Sprintf("Texto visible: `%s` (menciona %s)\nDestino real: `%s`", truncate(link.Text, 100), brand, link.URL), true, true
+		}
+	}
+	return "", false, false
+}
+
+var giftWords = []string{"nitro", "gift", "free", "claim", "reward", "regalo", "gratis", "reclama"}
+
+func containsAny(s string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) checkLinks(content string, embeds []*discordgo.MessageEmbed) *Detection {
+	for _, link := range ExtractLinks(content, embeds) {
+		if detail, protected, ok := checkMaskedLink(link); ok {
+			mute := time.Duration(0)
+			if protected {
+				mute = 7 * 24 * time.Hour
+			}
+			return &Detection{Reason: "Enlace Enmascarado", Detail: "El texto del enlace no coincide con su destino.\n" + detail, Mute: mute}
+		}
+
+		if m.Domains == nil {
+			continue
+		}
+		if v := m.Domains.Check(link.Host); v.Blocked {
+			reason := "Dominio Sospechoso"
+			if v.ListMatch != "" {
+				reason = "Dominio Bloqueado"
+			}
+			detail := fmt.Sprintf("%s\nDominio: `%s`\n%s\nURL: `%s`", LINK_SOSPECHOSO, v.Host, v.Reason, truncate(link.URL, 200))
+			return &Detection{Reason: reason, Detail: detail, Mute: 7 * 24 * time.Hour}
+		}
+	}
+	return nil
+}
+
+func (m *Manager) LoadDomainLists(dir string) {
+	m.Domains = NewDomainReputation(dir)
+}
@@ -0,0 +1,235 @@
+package automod
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// LockdownPermissions son los permisos que se niegan a @everyone.
+const LockdownPermissions = discordgo.PermissionSendMessages |
+	discordgo.PermissionSendMessagesInThreads |
+	discordgo.PermissionCreatePublicThreads |
+	discordgo.PermissionCreatePrivateThreads |
+	discordgo.PermissionAddReactions
+
+// Alcances de /lockdown start
+const (
+	LockdownChannel  = "channel"
+	LockdownCategory = "category"
+	LockdownAll      = "all"
+)
+
+var lockableChannels = []discordgo.ChannelType{
+	discordgo.ChannelTypeGuildText,
+	discordgo.ChannelTypeGuildNews,
+	discordgo.ChannelTypeGuildForum,
+	discordgo.ChannelTypeGuildVoice,
+}
+
+// OverwriteSnapshot es el overwrite de @everyone tal como estaba antes del
+// lockdown; Existed en false significa que no había ninguno.
+type OverwriteSnapshot struct {
+	Existed bool  `json:"existed"`
+	Allow   int64 `json:"allow,string"`
+	Deny    int64 `json:"deny,string"`
+}
+
+type Lockdown struct {
+	Since    time.Time                    `json:"since"`
+	Reason   string                       `json:"reason"`
+	By       string                       `json:"by"`
+	Channels map[string]OverwriteSnapshot `json:"channels"`
+}
+
+// LockdownStore persiste los lockdowns activos para poder restaurarlos tras un reinicio.
+type LockdownStore struct {
+	mu     sync.Mutex
+	path   string
+	active map[string]*Lockdown
+}
+
+func NewLockdownStore(path string) *LockdownStore {
+	l := &LockdownStore{path: path, active: make(map[string]*Lockdown)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error leyendo lockdowns en %s: %v\n", path, err)
+		}
+		return l
+	}
+	if err := json.Unmarshal(data, &l.active); err != nil {
+		fmt.Printf("Error deserializando lockdowns: %v\n", err)
+	}
+	return l
+}
+
+// saveLocked debe llamarse con l.mu tomado.
+func (l *LockdownStore) saveLocked() {
+	data, err := json.MarshalIndent(l.active, "", "  ")
+	if err != nil {
+		fmt.Printf("Error serializando lockdowns: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(l.path, data, 0644); err != nil {
+		fmt.Printf("Error guardando lockdowns en %s: %v\n", l.path, err)
+	}
+}
+
+func (l *LockdownStore) IsActive(guildID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.active[guildID]
+	return ok
+}
+
+// LockdownChannels resuelve el alcance a la lista de canales a bloquear.
+// Para "category" acepta la categoría o un canal dentro de ella.
+func LockdownChannels(s *discordgo.Session, guildID, scope, channelID string) ([]string, error) {
+	if scope == LockdownChannel {
+		return []string{channelID}, nil
+	}
+
+	channels, err := s.GuildChannels(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryID := ""
+	if scope == LockdownCategory {
+		for _, c := range channels {
+			if c.ID != channelID {
+				continue
+			}
+			categoryID = c.ParentID
+			if c.Type == discordgo.ChannelTypeGuildCategory {
+				categoryID = c.ID
+			}
+		}
+		if categoryID == "" {
+			return nil, fmt.Errorf("el canal no pertenece a ninguna categoría")
+		}
+	}
+
+	var ids []string
+	for _, c := range channels {
+		if !slices.Contains(lockableChannels, c.Type) {
+			continue
+		}
+		if categoryID != "" && c.ParentID != categoryID {
+			continue
+		}
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
+}
+
+// StartLockdown niega LockdownPermissions a @everyone en los canales dados,
+// guardando antes su overwrite exacto. Los canales ya bloqueados se saltean,
+// así que puede llamarse varias veces para sumar canales. Devuelve cuántos bloqueó.
+func (m *Manager) StartLockdown(s *discordgo.Session, guildID string, channelIDs []string, reason, by string) int {
+	l := m.Lockdowns
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, ok := l.active[guildID]
+	if !ok {
+		lock = &Lockdown{Since: time.Now(), Reason: reason, By: by, Channels: make(map[string]OverwriteSnapshot)}
+		l.active[guildID] = lock
+	}
+
+	locked := 0
+	for _, channelID := range channelIDs {
+		if _, done := lock.Channels[channelID]; done {
+			continue
+		}
+		channel, err := s.State.Channel(channelID)
+		if err != nil {
+			channel, err = s.Channel(channelID)
+		}
+		if err != nil {
+			fmt.Printf("Error obteniendo canal %s para el lockdown: %v\n", channelID, err)
+			continue
+		}
+
+		var snap OverwriteSnapshot
+		for _, o := range channel.PermissionOverwrites {
+			if o.Type == discordgo.PermissionOverwriteTypeRole && o.ID == guildID {
+				snap = OverwriteSnapshot{Existed: true, Allow: o.Allow, Deny: o.Deny}
+			}
+		}
+
+		allow := snap.Allow &^ LockdownPermissions
+		deny := snap.Deny | LockdownPermissions
+		if err := s.ChannelPermissionSet(channelID, guildID, discordgo.PermissionOverwriteTypeRole, allow, deny); err != nil {
+			fmt.Printf("Error bloqueando canal %s: %v\n", channelID, err)
+			continue
+		}
+		lock.Channels[channelID] = snap
+		locked++
+	}
+
+	if len(lock.Channels) == 0 {
+		delete(l.active, guildID)
+	}
+	l.saveLocked()
+
+	if locked > 0 {
+		m.LogEvent(s, guildID, &discordgo.MessageEmbed{
+			Title:       "🔒 Lockdown Iniciado",
+			Description: fmt.Sprintf("Por: %s\nMotivo: %s\nCanales bloqueados: %d", by, reason, locked),
+			Color:       0xe74c3c,
+			Timestamp:   time.Now().Format(time.RFC3339),
+		})
+	}
+	return locked
+}
+
+// EndLockdown restaura el overwrite de @everyone de cada canal bloqueado tal
+// como estaba. Los canales que fallan quedan guardados para reintentar.
+func (m *Manager) EndLockdown(s *discordgo.Session, guildID, by string) (int, bool) {
+	l := m.Lockdowns
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, ok := l.active[guildID]
+	if !ok {
+		return 0, false
+	}
+
+	restored := 0
+	for channelID, snap := range lock.Channels {
+		var err error
+		if snap.Existed {
+			err = s.ChannelPermissionSet(channelID, guildID, discordgo.PermissionOverwriteTypeRole, snap.Allow, snap.Deny)
+		} else {
+			err = s.ChannelPermissionDelete(channelID, guildID)
+		}
+		// 404: el canal ya no existe, no hay nada que restaurar
+		if err != nil && !isNotFound(err) {
+			fmt.Printf("Error restaurando canal %s: %v\n", channelID, err)
+			continue
+		}
+		delete(lock.Channels, channelID)
+		restored++
+	}
+
+	if len(lock.Channels) == 0 {
+		delete(l.active, guildID)
+		m.Scheduler.CancelFor(guildID, JobLockdownEnd, "")
+	}
+	l.saveLocked()
+
+	m.LogEvent(s, guildID, &discordgo.MessageEmbed{
+		Title:       "🔓 Lockdown Terminado",
+		Description: fmt.Sprintf("Por: %s\nDuración: %s\nCanales restaurados: %d", by, time.Since(lock.Since).Round(time.Second), restored),
+		Color:       0x2ecc71,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	})
+	return restored, true
+}
@@ -7,6 +7,7 @@ import (
 	"image/jpeg"
 	"os"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -19,35 +20,113 @@ type Config struct {
 	LogChannelID    string `json:"log_channel_id"`
 	EventsChannelID string `json:"events_channel_id"`
 	NSFWDetection   bool   `json:"nsfw_detection"`
+
+	// Entradas extra (o vacías para desactivar) del mapa leet, ej: {"9": "g"}
+	LeetMap map[string]string `json:"leet_map,omitempty"`
+
+	Duplicates *DuplicateConfig `json:"duplicates,omitempty"`
+
+	// Minutos de mensajes a purgar por regla, ej: {"Imagen Scam": 10}
+	PurgeRules map[string]int `json:"purge_rules,omitempty"`
+
+	RateLimits *RateLimitConfig `json:"rate_limits,omitempty"`
+	Mentions   *MentionConfig   `json:"mentions,omitempty"`
+
+	ResolveRedirects bool `json:"resolve_redirects,omitempty"`
+
+	// IDs de servidores a los que se permite invitar, además del propio
+	AllowedInviteGuilds []string `json:"allowed_invite_guilds,omitempty"`
+
+	Attachments *AttachmentConfig `json:"attachments,omitempty"`
+
+	OCR bool `json:"ocr,omitempty"`
+
+	Raid *RaidConfig `json:"raid,omitempty"`
+
+	QuarantineRoleID    string            `json:"quarantine_role_id,omitempty"`
+	QuarantineChannelID string            `json:"quarantine_channel_id,omitempty"`
+	QuarantineMode      bool              `json:"quarantine_mode,omitempty"`
+	AccountAge          *AccountAgeConfig `json:"account_age,omitempty"`
+
+	StaffRoles    []string           `json:"staff_roles,omitempty"`
+	NameScreening *NameScreenConfig  `json:"name_screening,omitempty"`
+	Avatars       *AvatarConfig      `json:"avatars,omitempty"`
+	BanEvasion    *BanEvasionConfig  `json:"ban_evasion,omitempty"`
+	Honeypot      *HoneypotConfig    `json:"honeypot,omitempty"`
+	Compromised   *CompromisedConfig `json:"compromised,omitempty"`
+
+	// Letras o dígitos extra permitidos entre las palabras de una frase de scam
+	PhraseWindow int `json:"phrase_window,omitempty"`
+
+	// Filtros agregados con /add-filter, solo para este servidor
+	CustomFilters []CustomFilter `json:"custom_filters,omitempty"`
 }
 
 type Manager struct {
-	Scanner        *CLIPScanner
-	ScamFilters    []*regexp2.Regexp
-	SpamFilters    []IFilter
-	GuildConfig    map[string]*Config
-	mu             sync.RWMutex
-	mentionHistory map[string][]time.Time
-	messageHistory map[string][]time.Time
-	configPath     string
-	activityPath   string
-	LastActivity   map[string]time.Time
+	Scanner         *CLIPScanner
+	Messages        *MessageCache
+	Duplicates      *DuplicateDetector
+	RateLimiter     *RateLimiter
+	Domains         *DomainReputation
+	Resolver        *LinkResolver
+	Invites         *InviteResolver
+	MaliciousHashes *DomainList
+	OCR             *OCREngine
+	Avatars         *AvatarLibrary
+	Raids           *RaidMonitor
+	Lockdowns       *LockdownStore
+	Bans            *BanStore
+	Quarantines     *QuarantineStore
+	Scheduler       *Scheduler
+	Hours           *HourHistogram
+	ScamPhrases     *PhraseMatcher
+	SpamFilters     []IFilter
+	GuildConfig     map[string]*Config
+	mu              sync.RWMutex
+	mentionHistory  map[string][]mentionEvent
+	mentionSweep    time.Time
+	staff           staffCache
+	bursts          activityBursts
+	members         memberSnapshots
+	configPath      string
+	activityPath    string
+	filtersPath     string
+	guildFilters    map[string][]IFilter
+	LastActivity    map[string]time.Time
+	FilterStats     FilterStats
 }
 
 func NewManager(configPath string) *Manager {
 	m := &Manager{
 		Scanner:        CLIPScan(),
-		ScamFilters:    GetScamFilterList(),
+		Messages:       NewMessageCache(messageCacheTTL),
+		Duplicates:     NewDuplicateDetector(),
+		RateLimiter:    NewRateLimiter(),
+		Resolver:       NewLinkResolver(),
+		Invites:        NewInviteResolver(),
+		Avatars:        NewAvatarLibrary(),
+		Raids:          NewRaidMonitor(strings.TrimSuffix(configPath, ".json") + "_raid.json"),
+		Lockdowns:      NewLockdownStore(strings.TrimSuffix(configPath, ".json") + "_lockdown.json"),
+		Bans:           NewBanStore(strings.TrimSuffix(configPath, ".json") + "_bans.json"),
+		Quarantines:    NewQuarantineStore(strings.TrimSuffix(configPath, ".json") + "_quarantine.json"),
+		Scheduler:      NewScheduler(strings.TrimSuffix(configPath, ".json") + "_jobs.json"),
+		Hours:          NewHourHistogram(strings.TrimSuffix(configPath, ".json") + "_hours.json"),
+		ScamPhrases:    NewPhraseMatcher(BasePhrases),
 		SpamFilters:    SpamFilterList,
 		GuildConfig:    make(map[string]*Config),
-		mentionHistory: make(map[string][]time.Time),
-		messageHistory: make(map[string][]time.Time),
+		guildFilters:   make(map[string][]IFilter),
+		mentionHistory: make(map[string][]mentionEvent),
+		staff:          staffCache{guilds: make(map[string]staffEntry), refreshing: make(map[string]bool)},
+		bursts:         activityBursts{users: make(map[string]burst)},
+		members:        memberSnapshots{members: make(map[string]memberSnapshot)},
 		LastActivity:   make(map[string]time.Time),
 		configPath:     configPath,
 		activityPath:   strings.TrimSuffix(configPath, ".json") + "_activity.json",
+		filtersPath:    strings.TrimSuffix(configPath, ".json") + "_filters.json",
 	}
 	m.LoadConfig()
 	m.LoadActivity()
+	m.LoadCustomFilters()
 	return m
 }
 
@@ -99,6 +178,25 @@ func (m *Manager) SetNSFWDetection(guildID string, enabled bool) {
 	m.SaveConfig()
 }
 
+func (m *Manager) SetPhraseWindow(guildID string, window int) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	m.GuildConfig[guildID].PhraseWindow = window
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+func (m *Manager) GetPhraseWindow(guildID string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok {
+		return cfg.PhraseWindow
+	}
+	return 0
+}
+
 func (m *Manager) IsNSFWEnabled(guildID string) bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -108,44 +206,72 @@ func (m *Manager) IsNSFWEnabled(guildID string) bool {
 	return false
 }
 
+// Detection es el resultado de un detector: razón, detalle para el log y
+// duración del aislamiento (0 para solo borrar).
+type Detection struct {
+	Reason string
+	Detail string
+	Mute   time.Duration
+}
+
+// editedSuffix marca las sanciones aplicadas por una edición de mensaje.
+const editedSuffix = " (mensaje editado)"
+
 func (m *Manager) AnalyzeMessage(s *discordgo.Session, msg *discordgo.MessageCreate) {
 	if msg.Author.Bot {
 		return
 	}
 
-	content := msg.Content
-	for _, filter := range m.SpamFilters {
-		match, _ := filter.Filter.FindStringMatch(content)
-		if match != nil {
-			detail := fmt.Sprintf("Match: `%s`\nRegex: `%s`", match.String(), filter.Filter.String())
-			if filter.WarnMessage != "" {
-				detail = filter.WarnMessage + "\n" + detail
-			}
-			muteDur := time.Duration(0)
-			if filter.Mute {
-				muteDur = 7 * 24 * time.Hour
-			}
-			m.TakeAction(s, msg, "Spam Filter", detail, muteDur, nil)
-			return
-		}
+	if m.checkHoneypot(s, msg) {
+		return
 	}
 
-	for _, filter := range m.ScamFilters {
-		match, _ := filter.FindStringMatch(content)
-		if match != nil {
-			detail := fmt.Sprintf("Posible estafa detectada en el texto.\nMatch: `%s`\nRegex: `%s`", match.String(), filter.String())
-			m.TakeAction(s, msg, "Scam Phrase Filter", detail, 0, nil)
-			return
-		}
+	m.Messages.Add(msg.Message)
+	m.members.remember(msg.GuildID, msg.Member, msg.Author)
+
+	if m.checkCompromised(s, msg) {
+		return
+	}
+
+	if det := m.detectText(msg.GuildID, msg.Content); det != nil {
+		m.TakeAction(s, msg, det.Reason, det.Detail, det.Mute, nil)
+		return
+	}
+
+	if det := m.detectText(msg.GuildID, embedText(msg.Embeds)); det != nil {
+		m.TakeAction(s, msg, det.Reason, "En embed.\n"+det.Detail, det.Mute, nil)
+		return
+	}
+
+	if det := m.checkInvites(s, msg.GuildID, msg.Content+"\n"+embedText(msg.Embeds)); det != nil {
+		m.TakeAction(s, msg, det.Reason, det.Detail, det.Mute, nil)
+		return
 	}
 
-	if len(msg.Mentions) > 5 {
-		m.TakeAction(s, msg, "Mass Mention", "Demasiadas menciones en un solo mensaje.", 7*24*time.Hour, nil)
+	if det := m.checkAttachments(s, msg); det != nil {
+		m.TakeAction(s, msg, det.Reason, det.Detail, det.Mute, nil)
 		return
 	}
 
-	if m.isSpamming(msg.Author.ID) {
-		m.TakeAction(s, msg, "Spam", "Enviando mensajes demasiado rápido.", 5*time.Minute, nil)
+	if det := m.checkNewAccount(msg); det != nil {
+		m.TakeAction(s, msg, det.Reason, det.Detail, det.Mute, nil)
+		return
+	}
+
+	if det := m.checkDuplicates(s, msg); det != nil {
+		m.TakeAction(s, msg, det.Reason, det.Detail, det.Mute, nil)
+		return
+	}
+
+	if det := m.checkMentions(s, msg); det != nil {
+		m.TakeAction(s, msg, det.Reason, det.Detail, det.Mute, nil)
+		return
+	}
+
+	m.checkRedirects(s, msg)
+
+	if det := m.checkRateLimit(s, msg); det != nil {
+		m.TakeAction(s, msg, det.Reason, det.Detail, det.Mute, nil)
 		return
 	}
 
@@ -187,49 +313,7 @@ func (m *Manager) AnalyzeMessage(s *discordgo.Session, msg *discordgo.MessageCre
 					continue
 				}
 
-				go func(attachment *discordgo.MessageAttachment) {
-					img, err := DownloadImage(attachment.URL)
-					if err == nil {
-						start := time.Now()
-						var mStart, mEnd runtime.MemStats
-						runtime.ReadMemStats(&mStart)
-
-						match, name, score, crop := m.Scanner.Compare(img)
-
-						elapsed := time.Since(start)
-
-						runtime.ReadMemStats(&mEnd)
-
-						memUsedKB := int64(mEnd.HeapInuse-mStart.HeapInuse) / 1024
-						if memUsedKB < 0 {
-							memUsedKB = 0
-						}
-
-						if match {
-							once.Do(func() {
-								detail := fmt.Sprintf("Imagen detectada: %s\nScore: %.3f\nTiempo: %s\nMemoria: %s",
-									name, score, elapsed, formatMemory(float64(memUsedKB)))
-								m.TakeAction(s, msg, "Imagen Scam", detail, 7*24*time.Hour, crop)
-							})
-							return
-						}
-					}
-
-					if m.IsNSFWEnabled(msg.GuildID) {
-						isNSFW, err := CheckNSFW(attachment.URL)
-						if err == nil && isNSFW {
-							var crop []byte
-							if img != nil {
-								var buf bytes.Buffer
-								jpeg.Encode(&buf, img, &jpeg.Options{Quality: 60})
-								crop = buf.Bytes()
-							}
-							once.Do(func() {
-								m.TakeAction(s, msg, "Contenido NSFW", "Imagen detectada como no segura para el servidor.", 7*24*time.Hour, crop)
-							})
-						}
-					}
-				}(att)
+				go m.analyzeImage(s, msg, att, &once)
 			}
 		}()
 	}
@@ -238,6 +322,221 @@ func (m *Manager) AnalyzeMessage(s *discordgo.Session, msg *discordgo.MessageCre
 	m.LastActivity[msg.Author.ID] = time.Now()
 	m.mu.Unlock()
 	m.SaveActivity()
+	m.Hours.Record(msg.Author.ID, time.Now())
+}
+
+// analyzeImage busca QR sospechosos, la compara con la biblioteca de scams, lee
+// su texto con OCR y, si está activado, revisa si es NSFW. Solo se sanciona una vez por mensaje.
+func (m *Manager) analyzeImage(s *discordgo.Session, msg *discordgo.MessageCreate, attachment *discordgo.MessageAttachment, once *sync.Once) {
+	img, err := DownloadImage(attachment.URL)
+	if err == nil {
+		if det := m.checkQR(s, msg.GuildID, img); det != nil {
+			var buf bytes.Buffer
+			jpeg.Encode(&buf, img, &jpeg.Options{Quality: 60})
+			once.Do(func() {
+				m.TakeAction(s, msg, det.Reason, det.Detail, det.Mute, buf.Bytes())
+			})
+			return
+		}
+
+		start := time.Now()
+		var mStart, mEnd runtime.MemStats
+		runtime.ReadMemStats(&mStart)
+
+		match, name, score, crop := m.Scanner.Compare(img)
+
+		elapsed := time.Since(start)
+
+		runtime.ReadMemStats(&mEnd)
+
+		memUsedKB := int64(mEnd.HeapInuse-mStart.HeapInuse) / 1024
+		if memUsedKB < 0 {
+			memUsedKB = 0
+		}
+
+		if match {
+			once.Do(func() {
+				detail := fmt.Sprintf("Imagen detectada: %s\nScore: %.3f\nTiempo: %s\nMemoria: %s",
+					name, score, elapsed, formatMemory(float64(memUsedKB)))
+				m.TakeAction(s, msg, "Imagen Scam", detail, 7*24*time.Hour, crop)
+			})
+			return
+		}
+
+		if det := m.checkOCR(msg.GuildID, img); det != nil {
+			var buf bytes.Buffer
+			jpeg.Encode(&buf, img, &jpeg.Options{Quality: 60})
+			once.Do(func() {
+				m.TakeAction(s, msg, det.Reason, det.Detail, det.Mute, buf.Bytes())
+			})
+			return
+		}
+	}
+
+	if m.IsNSFWEnabled(msg.GuildID) {
+		isNSFW, err := CheckNSFW(attachment.URL)
+		if err == nil && isNSFW {
+			var crop []byte
+			if img != nil {
+				var buf bytes.Buffer
+				jpeg.Encode(&buf, img, &jpeg.Options{Quality: 60})
+				crop = buf.Bytes()
+			}
+			once.Do(func() {
+				m.TakeAction(s, msg, "Contenido NSFW", "Imagen detectada como no segura para el servidor.", 7*24*time.Hour, crop)
+			})
+		}
+	}
+}
+
+// detectText corre los filtros de spam y las frases de scam sobre el texto
+// original y, solo si cambia al normalizarlo, también sobre la forma normalizada.
+func (m *Manager) detectText(guildID, content string) *Detection {
+	if content == "" {
+		return nil
+	}
+
+	normalized := m.NormalizeContent(guildID, content)
+
+	m.mu.RLock()
+	spamFilters := slices.Concat(m.SpamFilters, m.guildFilters[guildID])
+	m.mu.RUnlock()
+
+	start := time.Now()
+	for _, filter := range spamFilters {
+		if time.Since(start) > FilterBudget {
+			fmt.Printf("Presupuesto de filtros agotado (%s) con un texto de %d caracteres\n", FilterBudget, len(content))
+			break
+		}
+		if match, ok := m.findMatch(filter.Filter, content, normalized); ok {
+			detail := fmt.Sprintf("%s\nRegex: `%s`", match, filter.Filter.String())
+			if filter.WarnMessage != "" {
+				detail = filter.WarnMessage + "\n" + detail
+			}
+			muteDur := time.Duration(0)
+			if filter.Mute {
+				muteDur = 7 * 24 * time.Hour
+			}
+			return &Detection{Reason: "Spam Filter", Detail: detail, Mute: muteDur}
+		}
+	}
+
+	window := m.GetPhraseWindow(guildID)
+	if match, ok := m.ScamPhrases.Find(content, window); ok {
+		detail := fmt.Sprintf("Posible estafa detectada en el texto.\nMatch: `%s`\nFrase: `%s`", match.Text, match.Phrase)
+		return &Detection{Reason: "Scam Phrase Filter", Detail: detail}
+	}
+	if normalized != content {
+		if match, ok := m.ScamPhrases.Find(normalized, window); ok {
+			detail := fmt.Sprintf("Posible estafa detectada en el texto.\nMatch (normalizado): `%s`\nFrase: `%s`\nTexto normalizado: `%s`", match.Text, match.Phrase, truncate(normalized, 300))
+			return &Detection{Reason: "Scam Phrase Filter", Detail: detail}
+		}
+	}
+
+	if det := m.checkLinks(content, nil); det != nil {
+		return det
+	}
+
+	return nil
+}
+
+// embedText junta el texto visible de los embeds (los links se desenrollan
+// con una edición posterior, así que es donde suele aparecer el scam).
+func embedText(embeds []*discordgo.MessageEmbed) string {
+	var parts []string
+	for _, e := range embeds {
+		parts = append(parts, e.URL, e.Title, e.Description)
+		if e.Author != nil {
+			parts = append(parts, e.Author.Name, e.Author.URL)
+		}
+		if e.Footer != nil {
+			parts = append(parts, e.Footer.Text)
+		}
+		for _, f := range e.Fields {
+			parts = append(parts, f.Name, f.Value)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+// AnalyzeMessageUpdate vuelve a pasar los detectores de texto y embeds sobre
+// un mensaje editado, usando la caché para conocer el contenido anterior.
+func (m *Manager) AnalyzeMessageUpdate(s *discordgo.Session, upd *discordgo.MessageUpdate) {
+	if upd.GuildID == "" {
+		return
+	}
+
+	prev, cached := m.Messages.Get(upd.ID)
+	if upd.Author == nil {
+		if !cached {
+			return
+		}
+		upd.Author = &discordgo.User{ID: prev.AuthorID, Username: prev.AuthorName}
+	}
+	if upd.Author.Bot {
+		return
+	}
+
+	content := upd.Content
+	if content == "" && cached {
+		content = prev.Content
+	}
+
+	var det *Detection
+	if !cached || content != prev.Content {
+		det = m.detectText(upd.GuildID, content)
+	}
+	if det == nil {
+		if det = m.detectText(upd.GuildID, embedText(upd.Embeds)); det != nil {
+			det.Detail = "En embed.\n" + det.Detail
+		}
+	}
+	if det == nil {
+		det = m.checkInvites(s, upd.GuildID, content+"\n"+embedText(upd.Embeds))
+	}
+	m.Messages.UpdateContent(upd.ID, content)
+
+	msg := &discordgo.MessageCreate{Message: upd.Message}
+	msg.Content = content
+
+	if det == nil {
+		if !cached || content != prev.Content {
+			m.checkRedirects(s, msg)
+		}
+		return
+	}
+
+	before := "(desconocido)"
+	if cached {
+		before = prev.Content
+	}
+	detail := fmt.Sprintf("%s\n**Antes:** `%s`\n**Después:** `%s`", det.Detail, truncate(before, 300), truncate(content, 300))
+	m.TakeAction(s, msg, det.Reason+editedSuffix, detail, det.Mute, nil)
+}
+
+// findMatch prueba el filtro contra el texto original y, si no hay match,
+// contra su forma normalizada. Devuelve la línea de detalle para el log.
+func (m *Manager) findMatch(re *regexp2.Regexp, content, normalized string) (string, bool) {
+	if match := m.matchFilter(re, content); match != nil {
+		return fmt.Sprintf("Match: `%s`", match.String()), true
+	}
+
+	if normalized == content {
+		return "", false
+	}
+
+	if match := m.matchFilter(re, normalized); match != nil {
+		return fmt.Sprintf("Match (normalizado): `%s`\nTexto normalizado: `%s`", match.String(), truncate(normalized, 300)), true
+	}
+	return "", false
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
 }
 
 func formatMemory(b float64) string {
@@ -265,18 +564,41 @@ func formatMemory(b float64) string {
 func (m *Manager) TakeAction(s *discordgo.Session, msg *discordgo.MessageCreate, reason, detail string, muteDuration time.Duration, cropData []byte) {
 	s.ChannelMessageDelete(msg.ChannelID, msg.ID)
 
+	if window := m.purgeWindow(msg.GuildID, reason); window > 0 {
+		count, channels := m.PurgeRecentMessages(s, msg.GuildID, msg.Author.ID, window, msg.ID)
+		if count > 0 {
+			detail += "\n" + formatPurge(count, channels)
+		}
+	}
+
 	if muteDuration > 0 {
 		until := time.Now().Add(muteDuration)
-		if err := s.GuildMemberTimeout(msg.GuildID, msg.Author.ID, &until); err != nil {
-			fmt.Printf("Error muteando usuario %s: %v\n", msg.Author.ID, err)
+		quarantined := false
+		if m.IsQuarantineMode(msg.GuildID) {
+			if err := m.QuarantineMember(s, msg.GuildID, msg.Author.ID, reason, "Sentinel", muteDuration); err != nil {
+				fmt.Printf("Error poniendo en cuarentena a %s: %v\n", msg.Author.ID, err)
+			} else {
+				quarantined = true
+				detail += fmt.Sprintf("\nCuarentena hasta <t:%d:f>", until.Unix())
+			}
+		}
+		if !quarantined {
+			if err := s.GuildMemberTimeout(msg.GuildID, msg.Author.ID, &until); err != nil {
+				fmt.Printf("Error muteando usuario %s: %v\n", msg.Author.ID, err)
+			}
 		}
 	}
 
-	logChannel := m.GetLogChannel(msg.GuildID)
+	m.LogSanction(s, msg.GuildID, msg.Author, reason, detail, cropData)
+}
+
+// LogSanction publica una sanción en el canal de logs, con la evidencia si la hay.
+func (m *Manager) LogSanction(s *discordgo.Session, guildID string, user *discordgo.User, reason, detail string, cropData []byte) {
+	logChannel := m.GetLogChannel(guildID)
 	if logChannel != "" {
 		embed := &discordgo.MessageEmbed{
 			Title:       "🚨 Automod",
-			Description: fmt.Sprintf("Usuario: <@%s> (%s)\nRazón: **%s**\nDetalle: %s", msg.Author.ID, msg.Author.String(), reason, detail),
+			Description: fmt.Sprintf("Usuario: <@%s> (%s)\nRazón: **%s**\nDetalle: %s", user.ID, user.String(), reason, detail),
 			Color:       0xff0000,
 			Timestamp:   time.Now().Format(time.RFC3339),
 			Footer: &discordgo.MessageEmbedFooter{
@@ -305,26 +627,6 @@ func (m *Manager) TakeAction(s *discordgo.Session, msg *discordgo.MessageCreate,
 	}
 }
 
-func (m *Manager) isSpamming(userID string) bool {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	now := time.Now()
-	history := m.messageHistory[userID]
-
-	var newHistory []time.Time
-	for _, t := range history {
-		if now.Sub(t) < 5*time.Second {
-			newHistory = append(newHistory, t)
-		}
-	}
-
-	newHistory = append(newHistory, now)
-	m.messageHistory[userID] = newHistory
-
-	return len(newHistory) > 5
-}
-
 func (m *Manager) LogEvent(s *discordgo.Session, guildID string, embed *discordgo.MessageEmbed) {
 	channelID := m.GetEventsChannel(guildID)
 	if channelID != "" {
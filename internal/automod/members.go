@@ -0,0 +1,91 @@
+package automod
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleMemberJoin corre los controles de entrada de un miembro nuevo.
+func (m *Manager) HandleMemberJoin(s *discordgo.Session, ev *discordgo.GuildMemberAdd) {
+	if ev.User == nil || ev.User.Bot {
+		return
+	}
+
+	// Salir y volver a entrar no saca de la cuarentena
+	if m.reapplyQuarantine(s, ev.GuildID, ev.User.ID) {
+		return
+	}
+
+	m.members.remember(ev.GuildID, ev.Member, ev.User)
+	avatar := m.scanAvatar(ev.Member)
+	m.checkAccountAge(s, ev)
+	m.checkMemberNames(s, ev.GuildID, ev.Member)
+	m.checkAvatar(s, ev.GuildID, ev.Member, avatar)
+	m.checkBanEvasion(s, ev.GuildID, ev.Member, avatar)
+	m.checkRaid(s, ev, avatar)
+}
+
+// HandleMemberUpdate vuelve a revisar nombres y avatar cuando el miembro los
+// cambia. Sin el estado anterior en caché se revisa todo.
+func (m *Manager) HandleMemberUpdate(s *discordgo.Session, ev *discordgo.GuildMemberUpdate) {
+	if ev.Member == nil || ev.User == nil || ev.User.Bot {
+		return
+	}
+	m.members.remember(ev.GuildID, ev.Member, ev.User)
+	before := ev.BeforeUpdate
+	if before == nil || before.User == nil || !slices.Equal(memberNames(before), memberNames(ev.Member)) {
+		m.checkMemberNames(s, ev.GuildID, ev.Member)
+	}
+	if before == nil || before.User == nil || before.Avatar != ev.Member.Avatar || before.User.Avatar != ev.User.Avatar {
+		m.checkAvatar(s, ev.GuildID, ev.Member, m.scanAvatar(ev.Member))
+	}
+}
+
+// HandleGuildCreate pide por el gateway los miembros de los servidores
+// grandes, que no vienen en GUILD_CREATE, para tenerlos en el estado.
+func (m *Manager) HandleGuildCreate(s *discordgo.Session, ev *discordgo.GuildCreate) {
+	if !ev.Large {
+		return
+	}
+	if err := s.RequestGuildMembers(ev.ID, "", 0, "", false); err != nil {
+		fmt.Printf("Error pidiendo los miembros de %s: %v\n", ev.ID, err)
+	}
+}
+
+// HandleMembersChunk rehace la lista de staff cuando llega el último bloque de miembros.
+func (m *Manager) HandleMembersChunk(s *discordgo.Session, ev *discordgo.GuildMembersChunk) {
+	if ev.ChunkIndex == ev.ChunkCount-1 {
+		m.forgetStaffNames(ev.GuildID)
+	}
+}
+
+// accountAge calcula la edad de la cuenta a partir de su snowflake.
+func accountAge(userID string) time.Duration {
+	created, err := discordgo.SnowflakeTimestamp(userID)
+	if err != nil {
+		return 0
+	}
+	return time.Since(created)
+}
+
+func (m *Manager) SetQuarantineRole(guildID, roleID string) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	m.GuildConfig[guildID].QuarantineRoleID = roleID
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+func (m *Manager) GetQuarantineRole(guildID string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok {
+		return cfg.QuarantineRoleID
+	}
+	return ""
+}
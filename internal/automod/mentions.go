@@ -0,0 +1,181 @@
+package automod
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type MentionConfig struct {
+	UserWeight float64 `json:"user_weight"`
+	RoleWeight float64 `json:"role_weight"`
+	// Peso de un @everyone/@here escrito por alguien sin permiso para usarlo
+	EveryoneWeight float64 `json:"everyone_weight"`
+	// Peso extra por mencionar un rol protegido o a alguien que lo tiene
+	ProtectedWeight  float64  `json:"protected_weight"`
+	ProtectedRoles   []string `json:"protected_roles,omitempty"`
+	MessageThreshold float64  `json:"message_threshold"`
+	WindowThreshold  float64  `json:"window_threshold"`
+	WindowSeconds    int      `json:"window_seconds"`
+	MuteHours        int      `json:"mute_hours"`
+}
+
+var DefaultMentionConfig = MentionConfig{
+	UserWeight:       1,
+	RoleWeight:       2,
+	EveryoneWeight:   5,
+	ProtectedWeight:  3,
+	MessageThreshold: 6,
+	WindowThreshold:  12,
+	WindowSeconds:    30,
+	MuteHours:        7 * 24,
+}
+
+// Cada cuánto se borra el historial de quienes dejaron de mencionar
+const mentionSweepPeriod = time.Minute
+
+type mentionEvent struct {
+	at     time.Time
+	weight float64
+}
+
+func (m *Manager) mentionConfig(guildID string) MentionConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok && cfg.Mentions != nil {
+		return *cfg.Mentions
+	}
+	return DefaultMentionConfig
+}
+
+// mentionScore pondera las menciones del mensaje y devuelve el desglose.
+func (m *Manager) mentionScore(s *discordgo.Session, msg *discordgo.MessageCreate, cfg MentionConfig) (float64, []string) {
+	var score float64
+	var parts []string
+
+	// Ni la auto-mención ni el ping de respuesta al autor citado cuentan
+	replyTo := ""
+	if msg.ReferencedMessage != nil && msg.ReferencedMessage.Author != nil {
+		replyTo = msg.ReferencedMessage.Author.ID
+	}
+
+	protected, users := 0, 0
+	for _, u := range msg.Mentions {
+		if u.ID == msg.Author.ID || u.ID == replyTo {
+			continue
+		}
+		users++
+		score += cfg.UserWeight
+		if len(cfg.ProtectedRoles) == 0 {
+			continue
+		}
+		// Solo el estado: una consulta REST por mención frenaría cada mensaje
+		if member, err := s.State.Member(msg.GuildID, u.ID); err == nil {
+			for _, role := range member.Roles {
+				if slices.Contains(cfg.ProtectedRoles, role) {
+					protected++
+					break
+				}
+			}
+		}
+	}
+	if users > 0 {
+		parts = append(parts, fmt.Sprintf("%d usuarios", users))
+	}
+
+	for _, role := range msg.MentionRoles {
+		score += cfg.RoleWeight
+		if slices.Contains(cfg.ProtectedRoles, role) {
+			protected++
+		}
+	}
+	if len(msg.MentionRoles) > 0 {
+		parts = append(parts, fmt.Sprintf("%d roles", len(msg.MentionRoles)))
+	}
+
+	if protected > 0 {
+		score += float64(protected) * cfg.ProtectedWeight
+		parts = append(parts, fmt.Sprintf("%d protegidos", protected))
+	}
+
+	lower := strings.ToLower(msg.Content)
+	if !msg.MentionEveryone && (strings.Contains(lower, "@everyone") || strings.Contains(lower, "@here")) {
+		// Sin el miembro en el estado no se puede saber si tiene permiso
+		perms, err := s.State.UserChannelPermissions(msg.Author.ID, msg.ChannelID)
+		if err == nil && perms&discordgo.PermissionMentionEveryone == 0 {
+			score += cfg.EveryoneWeight
+			parts = append(parts, "intento de @everyone/@here sin permiso")
+		}
+	}
+
+	return score, parts
+}
+
+// sweepMentionsLocked borra las claves sin menciones dentro de la ventana de
+// su servidor; debe llamarse con m.mu tomado.
+func (m *Manager) sweepMentionsLocked(now time.Time) {
+	m.mentionSweep = now
+	for key, events := range m.mentionHistory {
+		guildID, _, _ := strings.Cut(key, ":")
+		window := time.Duration(DefaultMentionConfig.WindowSeconds) * time.Second
+		if cfg, ok := m.GuildConfig[guildID]; ok && cfg.Mentions != nil {
+			window = time.Duration(cfg.Mentions.WindowSeconds) * time.Second
+		}
+		if len(events) == 0 || now.Sub(events[len(events)-1].at) >= window {
+			delete(m.mentionHistory, key)
+		}
+	}
+}
+
+// checkMentions suma el peso de las menciones del mensaje a una ventana
+// deslizante por usuario y sanciona si se pasa el umbral del mensaje o el
+// acumulado.
+func (m *Manager) checkMentions(s *discordgo.Session, msg *discordgo.MessageCreate) *Detection {
+	cfg := m.mentionConfig(msg.GuildID)
+	score, parts := m.mentionScore(s, msg, cfg)
+	if score == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	window := time.Duration(cfg.WindowSeconds) * time.Second
+	key := msg.GuildID + ":" + msg.Author.ID
+
+	m.mu.Lock()
+	var history []mentionEvent
+	total := score
+	for _, ev := range m.mentionHistory[key] {
+		if now.Sub(ev.at) < window {
+			history = append(history, ev)
+			total += ev.weight
+		}
+	}
+	m.mentionHistory[key] = append(history, mentionEvent{at: now, weight: score})
+	if now.Sub(m.mentionSweep) >= mentionSweepPeriod {
+		m.sweepMentionsLocked(now)
+	}
+	m.mu.Unlock()
+
+	var why string
+	switch {
+	case cfg.MessageThreshold > 0 && score >= cfg.MessageThreshold:
+		why = fmt.Sprintf("Demasiadas menciones en un solo mensaje (puntaje %.1f de %.1f).", score, cfg.MessageThreshold)
+	case cfg.WindowThreshold > 0 && total >= cfg.WindowThreshold:
+		why = fmt.Sprintf("Demasiadas menciones en %s (puntaje %.1f de %.1f en %d mensajes).", window, total, cfg.WindowThreshold, len(history)+1)
+	default:
+		return nil
+	}
+
+	m.mu.Lock()
+	delete(m.mentionHistory, key)
+	m.mu.Unlock()
+
+	return &Detection{
+		Reason: "Mass Mention",
+		Detail: why + "\nMenciones: " + strings.Join(parts, ", "),
+		Mute:   time.Duration(cfg.MuteHours) * time.Hour,
+	}
+}
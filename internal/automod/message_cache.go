@@ -0,0 +1,130 @@
+package automod
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	messageCacheTTL = 30 * time.Minute
+	messageCacheMax = 50000
+)
+
+type CachedMessage struct {
+	ID          string
+	ChannelID   string
+	GuildID     string
+	AuthorID    string
+	AuthorName  string
+	Content     string
+	Attachments int
+	Timestamp   time.Time
+}
+
+// MessageCache guarda los mensajes recientes para poder comparar ediciones
+// sin pedirle el historial a Discord.
+type MessageCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	messages  map[string]*CachedMessage
+	order     []string
+	lastPrune time.Time
+}
+
+func NewMessageCache(ttl time.Duration) *MessageCache {
+	return &MessageCache{
+		ttl:      ttl,
+		messages: make(map[string]*CachedMessage),
+	}
+}
+
+func (c *MessageCache) Add(msg *discordgo.Message) {
+	if msg.Author == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pruneLocked()
+
+	if _, ok := c.messages[msg.ID]; !ok {
+		c.order = append(c.order, msg.ID)
+	}
+	c.messages[msg.ID] = &CachedMessage{
+		ID:          msg.ID,
+		ChannelID:   msg.ChannelID,
+		GuildID:     msg.GuildID,
+		AuthorID:    msg.Author.ID,
+		AuthorName:  msg.Author.String(),
+		Content:     msg.Content,
+		Attachments: len(msg.Attachments),
+		Timestamp:   time.Now(),
+	}
+}
+
+func (c *MessageCache) Get(id string) (CachedMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msg, ok := c.messages[id]
+	if !ok {
+		return CachedMessage{}, false
+	}
+	return *msg, true
+}
+
+func (c *MessageCache) UpdateContent(id, content string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if msg, ok := c.messages[id]; ok {
+		msg.Content = content
+	}
+}
+
+func (c *MessageCache) Remove(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.messages, id)
+}
+
+// UserMessages devuelve los mensajes del usuario en el servidor desde since,
+// en todos los canales e hilos.
+func (c *MessageCache) UserMessages(guildID, userID string, since time.Time) []CachedMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var out []CachedMessage
+	for _, id := range c.order {
+		msg, ok := c.messages[id]
+		if !ok || msg.GuildID != guildID || msg.AuthorID != userID || msg.Timestamp.Before(since) {
+			continue
+		}
+		out = append(out, *msg)
+	}
+	return out
+}
+
+// pruneLocked descarta los mensajes vencidos. Como order está en orden de
+// llegada, basta con recortar desde el principio.
+func (c *MessageCache) pruneLocked() {
+	now := time.Now()
+	if now.Sub(c.lastPrune) < c.ttl/10 && len(c.order) < messageCacheMax {
+		return
+	}
+	c.lastPrune = now
+
+	cut := 0
+	for cut < len(c.order) {
+		msg, ok := c.messages[c.order[cut]]
+		if ok && now.Sub(msg.Timestamp) < c.ttl && len(c.order)-cut < messageCacheMax {
+			break
+		}
+		delete(c.messages, c.order[cut])
+		cut++
+	}
+	c.order = append([]string(nil), c.order[cut:]...)
+}
@@ -0,0 +1,302 @@
+package automod
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Acciones para nombres que no pasan el control
+const (
+	NameActionRename     = "rename"
+	NameActionQuarantine = "quarantine"
+	NameActionAlert      = "alert"
+)
+
+const staffCacheTTL = 15 * time.Minute
+
+type NameScreenConfig struct {
+	Enabled bool   `json:"enabled"`
+	Action  string `json:"action"`
+	// Parecido mínimo (0-1) con el nombre de un staff para considerarlo suplantación
+	Similarity float64 `json:"similarity"`
+}
+
+var DefaultNameScreenConfig = NameScreenConfig{
+	Enabled:    true,
+	Action:     NameActionAlert,
+	Similarity: 0.85,
+}
+
+// ReservedNames son nombres que solo usan las cuentas oficiales, ya en forma
+// de esqueleto y sin espacios ni signos.
+var ReservedNames = []string{
+	"discordsupport", "discordstaff", "discordteam", "discordmod", "discordadmin",
+	"discordtrustsafety", "discordnitro", "nitrogiveaway", "nitrogift", "steamsupport",
+	"robloxsupport", "soportediscord", "soportetecnico",
+}
+
+type staffName struct {
+	userID   string
+	skeleton string
+}
+
+type staffCache struct {
+	mu         sync.Mutex
+	guilds     map[string]staffEntry
+	refreshing map[string]bool
+}
+
+type staffEntry struct {
+	names []staffName
+	at    time.Time
+}
+
+func (m *Manager) nameScreenConfig(guildID string) NameScreenConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok && cfg.NameScreening != nil {
+		return *cfg.NameScreening
+	}
+	return DefaultNameScreenConfig
+}
+
+func (m *Manager) SetNameScreening(guildID, action string) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	if m.GuildConfig[guildID].NameScreening == nil {
+		screen := DefaultNameScreenConfig
+		m.GuildConfig[guildID].NameScreening = &screen
+	}
+	m.GuildConfig[guildID].NameScreening.Enabled = action != ""
+	if action != "" {
+		m.GuildConfig[guildID].NameScreening.Action = action
+	}
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+func (m *Manager) SetStaffRoles(guildID string, roles []string) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	m.GuildConfig[guildID].StaffRoles = roles
+	m.mu.Unlock()
+	m.SaveConfig()
+	m.forgetStaffNames(guildID)
+}
+
+func (m *Manager) GetStaffRoles(guildID string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok {
+		return slices.Clone(cfg.StaffRoles)
+	}
+	return nil
+}
+
+// isStaff indica si el miembro tiene alguno de los roles de staff del servidor.
+func (m *Manager) isStaff(guildID string, member *discordgo.Member) bool {
+	if member == nil {
+		return false
+	}
+	staff := m.GetStaffRoles(guildID)
+	for _, role := range member.Roles {
+		if slices.Contains(staff, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// nameSkeleton pliega confusables y deja solo letras y dígitos, para comparar nombres.
+func nameSkeleton(name string) string {
+	var b strings.Builder
+	for _, r := range foldLookalike(Skeleton(name, DefaultLeetMap)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func memberNames(member *discordgo.Member) []string {
+	var names []string
+	for _, n := range []string{member.Nick, member.User.GlobalName, member.User.Username} {
+		if n != "" && !slices.Contains(names, n) {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// staffNames devuelve los nombres de los miembros con rol de staff. Sale de
+// una copia que se rehace en segundo plano cada staffCacheTTL a partir de los
+// miembros en el estado de la sesión, sin llamadas a la API.
+func (m *Manager) staffNames(s *discordgo.Session, guildID string) []staffName {
+	if len(m.GetStaffRoles(guildID)) == 0 {
+		return nil
+	}
+
+	m.staff.mu.Lock()
+	entry, ok := m.staff.guilds[guildID]
+	stale := !ok || time.Since(entry.at) >= staffCacheTTL
+	refresh := stale && ok && !m.staff.refreshing[guildID]
+	if refresh {
+		m.staff.refreshing[guildID] = true
+	}
+	m.staff.mu.Unlock()
+
+	switch {
+	case !ok:
+		// La primera vez se arma en el momento: es memoria, no red
+		return m.refreshStaffNames(s, guildID)
+	case refresh:
+		go m.refreshStaffNames(s, guildID)
+	}
+	return entry.names
+}
+
+func (m *Manager) refreshStaffNames(s *discordgo.Session, guildID string) []staffName {
+	var members []*discordgo.Member
+	if guild, err := s.State.Guild(guildID); err == nil {
+		s.State.RLock()
+		members = slices.Clone(guild.Members)
+		s.State.RUnlock()
+	}
+
+	var names []staffName
+	for _, member := range members {
+		if member.User == nil || !m.isStaff(guildID, member) {
+			continue
+		}
+		for _, n := range memberNames(member) {
+			if sk := nameSkeleton(n); len(sk) >= 4 {
+				names = append(names, staffName{userID: member.User.ID, skeleton: sk})
+			}
+		}
+	}
+
+	m.staff.mu.Lock()
+	m.staff.guilds[guildID] = staffEntry{names: names, at: time.Now()}
+	delete(m.staff.refreshing, guildID)
+	m.staff.mu.Unlock()
+	return names
+}
+
+// forgetStaffNames descarta la copia para que la próxima consulta la rehaga.
+func (m *Manager) forgetStaffNames(guildID string) {
+	m.staff.mu.Lock()
+	delete(m.staff.guilds, guildID)
+	m.staff.mu.Unlock()
+}
+
+// nameHasLink busca invitaciones o dominios con un sufijo público real en el nombre.
+func nameHasLink(name string) string {
+	if codes := ExtractInviteCodes(name); len(codes) > 0 {
+		return "discord.gg/" + codes[0]
+	}
+	match, _ := visibleHostRe.FindStringMatch(name)
+	for match != nil {
+		host := strings.ToLower(match.String())
+		if looksLikeHost(host) {
+			return host
+		}
+		match, _ = visibleHostRe.FindNextMatch(match)
+	}
+	return ""
+}
+
+// screenName devuelve el motivo por el que un nombre no está permitido.
+func (m *Manager) screenName(s *discordgo.Session, guildID, userID, name string, cfg NameScreenConfig) string {
+	if link := nameHasLink(name); link != "" {
+		return fmt.Sprintf("Link en el nombre (`%s`)", link)
+	}
+
+	sk := nameSkeleton(name)
+	for _, reserved := range ReservedNames {
+		if strings.Contains(sk, reserved) {
+			return fmt.Sprintf("Nombre reservado (`%s`)", reserved)
+		}
+	}
+
+	if det := m.detectText(guildID, name); det != nil {
+		return fmt.Sprintf("El nombre salta el filtro %s", det.Reason)
+	}
+
+	if len(sk) < 4 {
+		return ""
+	}
+	for _, staff := range m.staffNames(s, guildID) {
+		if staff.userID == userID {
+			continue
+		}
+		longest := max(len([]rune(sk)), len([]rune(staff.skeleton)))
+		similarity := 1 - float64(levenshtein(sk, staff.skeleton))/float64(longest)
+		if similarity >= cfg.Similarity {
+			return fmt.Sprintf("Suplanta a <@%s> (parecido %.2f)", staff.userID, similarity)
+		}
+	}
+	return ""
+}
+
+// moderatedNick es el apodo que se pone al renombrar, para no volver a
+// revisar (y renombrar) en bucle a quien ya fue renombrado.
+func moderatedNick(userID string) string {
+	if len(userID) > 4 {
+		userID = userID[len(userID)-4:]
+	}
+	return "Usuario " + userID
+}
+
+// checkMemberNames revisa apodo, nombre global y nombre de usuario del miembro
+// y aplica la acción configurada al primero que no pasa.
+func (m *Manager) checkMemberNames(s *discordgo.Session, guildID string, member *discordgo.Member) {
+	if member == nil || member.User == nil || member.User.Bot {
+		return
+	}
+	cfg := m.nameScreenConfig(guildID)
+	if !cfg.Enabled || m.isStaff(guildID, member) {
+		return
+	}
+	if cfg.Action == NameActionRename && member.Nick == moderatedNick(member.User.ID) {
+		return
+	}
+
+	for _, name := range memberNames(member) {
+		reason := m.screenName(s, guildID, member.User.ID, name, cfg)
+		if reason == "" {
+			continue
+		}
+
+		var result string
+		var err error
+		switch cfg.Action {
+		case NameActionRename:
+			nick := moderatedNick(member.User.ID)
+			err = s.GuildMemberNickname(guildID, member.User.ID, nick)
+			result = fmt.Sprintf("Renombrado a `%s`", nick)
+		case NameActionQuarantine:
+			err = m.QuarantineMember(s, guildID, member.User.ID, "Nombre sospechoso", "Sentinel", 0)
+			result = "En cuarentena hasta /release"
+		default:
+			result = "Solo aviso"
+		}
+		if err != nil {
+			fmt.Printf("Error moderando el nombre de %s: %v\n", member.User.ID, err)
+			result += fmt.Sprintf(" (falló: %v)", err)
+		}
+
+		detail := fmt.Sprintf("%s\nNombre: `%s`\nAcción: %s", reason, strings.ReplaceAll(name, "`", ""), result)
+		m.LogSanction(s, guildID, member.User, "Nombre Sospechoso", detail, nil)
+		return
+	}
+}
@@ -0,0 +1,177 @@
+package automod
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables es un subconjunto escrito a mano de confusables.txt (Unicode
+// TR39): solo letras cirílicas, griegas y latinas extendidas o versalitas que
+// se ven como una única letra latina minúscula. No incluye los que mapean a
+// secuencias ni a otros alfabetos; fullwidth y alfanuméricos matemáticos ya
+// los resuelve NFKD en Skeleton.
+var confusables = map[rune]rune{
+	// Cirílico
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'һ': 'h', 'і': 'i', 'ї': 'i', 'ј': 'j',
+	'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y',
+	'х': 'x', 'ѕ': 's', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'ү': 'y', 'ӏ': 'l',
+	'А': 'a', 'В': 'b', 'Е': 'e', 'Ѕ': 's', 'І': 'i', 'Ј': 'j', 'К': 'k', 'М': 'm',
+	'Н': 'h', 'О': 'o', 'Р': 'p', 'С': 'c', 'Т': 't', 'У': 'y', 'Х': 'x', 'Ү': 'y',
+	// Griego
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w', 'Α': 'a', 'Β': 'b', 'Ε': 'e',
+	'Ζ': 'z', 'Η': 'h', 'Ι': 'i', 'Κ': 'k', 'Μ': 'm', 'Ν': 'n', 'Ο': 'o', 'Ρ': 'p',
+	'Τ': 't', 'Υ': 'y', 'Χ': 'x',
+	// Latín extendido y versalitas
+	'ı': 'i', 'ɩ': 'i', 'ɑ': 'a', 'ɒ': 'a', 'ɛ': 'e', 'ɡ': 'g', 'ɪ': 'i', 'ʟ': 'l',
+	'ℓ': 'l',
+	'ᴀ': 'a', 'ʙ': 'b', 'ᴄ': 'c', 'ᴅ': 'd', 'ᴇ': 'e', 'ɢ': 'g', 'ʜ': 'h', 'ᴊ': 'j',
+	'ᴋ': 'k', 'ᴍ': 'm', 'ɴ': 'n', 'ᴏ': 'o', 'ᴘ': 'p', 'ʀ': 'r', 'ꜱ': 's', 'ᴛ': 't',
+	'ᴜ': 'u', 'ᴠ': 'v', 'ᴡ': 'w', 'ʏ': 'y', 'ᴢ': 'z', 'ø': 'o', 'ð': 'd', 'ß': 's',
+}
+
+// DefaultLeetMap es el mapa de leetspeak por defecto. Cada servidor puede
+// sobreescribir o agregar entradas con Config.LeetMap.
+var DefaultLeetMap = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'|': 'l',
+	'€': 'e',
+}
+
+// Skeleton devuelve la forma canónica del texto: descompone formas de
+// compatibilidad (fullwidth, alfanuméricos matemáticos), quita caracteres de
+// ancho cero y marcas combinantes, pliega confusables y aplica el mapa leet.
+func Skeleton(text string, leet map[rune]rune) string {
+	var b strings.Builder
+	b.Grow(len(text))
+
+	for _, r := range norm.NFKD.String(text) {
+		if isInvisible(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) {
+			continue
+		}
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return applyLeet(b.String(), leet)
+}
+
+func isInvisible(r rune) bool {
+	switch {
+	case unicode.Is(unicode.Cf, r):
+		// ZWJ, ZWNJ, ZWSP, marcas de dirección, etc.
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F, r >= 0xE0100 && r <= 0xE01EF:
+		// Selectores de variación
+		return true
+	case r == 0x034F || r == 0x115F || r == 0x1160 || r == 0x3164 || r == 0xFFA0:
+		// Grapheme joiner y rellenos Hangul
+		return true
+	}
+	return false
+}
+
+// applyLeet reemplaza dígitos solo dentro de palabras que tienen letras
+// ("fr33" pero no "100") y símbolos solo si están entre alfanuméricos
+// ("fr@e" pero no "nitro!").
+func applyLeet(text string, leet map[rune]rune) string {
+	if len(leet) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+
+	// Cualquier espacio separa palabras ("100\nfree") y se copia tal cual
+	for rest := text; rest != ""; {
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end == 0 {
+			_, size := utf8.DecodeRuneInString(rest)
+			b.WriteString(rest[:size])
+			rest = rest[size:]
+			continue
+		}
+		if end < 0 {
+			end = len(rest)
+		}
+		runes := []rune(rest[:end])
+		rest = rest[end:]
+
+		hasLetter := false
+		for _, r := range runes {
+			if unicode.IsLetter(r) {
+				hasLetter = true
+				break
+			}
+		}
+
+		for i, r := range runes {
+			repl, ok := leet[r]
+			if !ok || !hasLetter {
+				b.WriteRune(r)
+				continue
+			}
+			if !unicode.IsDigit(r) {
+				surrounded := i > 0 && i < len(runes)-1 && isAlnum(runes[i-1]) && isAlnum(runes[i+1])
+				if !surrounded {
+					b.WriteRune(r)
+					continue
+				}
+			}
+			b.WriteRune(repl)
+		}
+	}
+
+	return b.String()
+}
+
+func isAlnum(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (m *Manager) leetMap(guildID string) map[rune]rune {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cfg, ok := m.GuildConfig[guildID]
+	if !ok || len(cfg.LeetMap) == 0 {
+		return DefaultLeetMap
+	}
+
+	merged := make(map[rune]rune, len(DefaultLeetMap)+len(cfg.LeetMap))
+	for k, v := range DefaultLeetMap {
+		merged[k] = v
+	}
+	for k, v := range cfg.LeetMap {
+		from, to := []rune(k), []rune(v)
+		if len(from) != 1 {
+			continue
+		}
+		if len(to) == 0 {
+			// Un valor vacío desactiva la entrada por defecto
+			delete(merged, from[0])
+			continue
+		}
+		merged[from[0]] = to[0]
+	}
+	return merged
+}
+
+// NormalizeContent devuelve el esqueleto del mensaje usando el mapa leet del servidor.
+func (m *Manager) NormalizeContent(guildID, content string) string {
+	return Skeleton(content, m.leetMap(guildID))
+}
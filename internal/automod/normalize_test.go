@@ -0,0 +1,36 @@
+package automod
+
+import (
+	"testing"
+	"unicode"
+)
+
+// Cada confusable debe ser un carácter no ASCII que se pliega a una sola
+// letra latina minúscula.
+func TestConfusablesSubset(t *testing.T) {
+	for from, to := range confusables {
+		if from <= unicode.MaxASCII {
+			t.Errorf("%q es ASCII, no hace falta plegarlo", from)
+		}
+		if to < 'a' || to > 'z' {
+			t.Errorf("%q se pliega a %q, que no es una letra latina minúscula", from, to)
+		}
+	}
+}
+
+func TestSkeleton(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"ԁіѕсоrd", "discord"},               // cirílico
+		{"ꜱᴛᴇᴀᴍ", "steam"},                   // versalitas
+		{"ｆｒｅｅ", "free"},                     // fullwidth, por NFKD
+		{"𝐧𝐢𝐭𝐫𝐨", "nitro"},                   // alfanuméricos matemáticos, por NFKD
+		{"n\u200bi\u200dtro\ufe0f", "nitro"}, // ancho cero y selectores
+		{"fr33 n1tr0", "free nitro"},
+		{"100\nfree", "100\nfree"},
+	}
+	for _, tt := range tests {
+		if got := Skeleton(tt.in, DefaultLeetMap); got != tt.want {
+			t.Errorf("Skeleton(%q) = %q, se esperaba %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package automod
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// Modelos de detección (DB) y reconocimiento (CRNN con CTC) al estilo
+// PaddleOCR, exportados a ONNX. Si falta alguno el OCR queda desactivado.
+const (
+	OCRDetModel = "models/ocr_det.onnx"
+	OCRRecModel = "models/ocr_rec.onnx"
+	OCRKeysFile = "models/ocr_keys.txt"
+
+	ocrDetSize   = 640
+	ocrRecHeight = 48
+	ocrRecWidth  = 320
+	ocrMaxBoxes  = 40
+	ocrBoxThresh = 0.3
+
+	// Tiempo máximo por imagen; las cajas que no alcanzan se descartan
+	ocrImageBudget = 3 * time.Second
+	// Segundos de CPU de OCR que puede gastar cada servidor por minuto
+	ocrGuildBudget = 20 * time.Second
+)
+
+var errOCRBudget = errors.New("presupuesto de OCR agotado")
+
+type ocrSession struct {
+	session *ort.AdvancedSession
+	input   *ort.Tensor[float32]
+	output  *ort.Tensor[float32]
+}
+
+func (s *ocrSession) Destroy() {
+	s.session.Destroy()
+	s.input.Destroy()
+	s.output.Destroy()
+}
+
+// OCREngine extrae texto de imágenes. Corre de a una imagen a la vez para
+// no competir con CLIP por la CPU.
+type OCREngine struct {
+	mu   sync.Mutex
+	det  *ocrSession
+	rec  *ocrSession
+	keys []string
+	// Pasos de tiempo y clases de la salida del reconocedor
+	steps, classes int
+
+	usageMu sync.Mutex
+	usage   map[string][]ocrUsage
+}
+
+type ocrUsage struct {
+	at      time.Time
+	elapsed time.Duration
+}
+
+func newOCRSession(path string, input, output ort.Shape, options *ort.SessionOptions) (*ocrSession, error) {
+	inputs, outputs, err := ort.GetInputOutputInfo(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) != 1 || len(outputs) != 1 {
+		return nil, fmt.Errorf("%s debe tener una entrada y una salida", path)
+	}
+
+	in, err := ort.NewEmptyTensor[float32](input)
+	if err != nil {
+		return nil, err
+	}
+	out, err := ort.NewEmptyTensor[float32](output)
+	if err != nil {
+		in.Destroy()
+		return nil, err
+	}
+	session, err := ort.NewAdvancedSession(path,
+		[]string{inputs[0].Name}, []string{outputs[0].Name},
+		[]ort.Value{in}, []ort.Value{out}, options)
+	if err != nil {
+		in.Destroy()
+		out.Destroy()
+		return nil, err
+	}
+	return &ocrSession{session: session, input: in, output: out}, nil
+}
+
+// NewOCREngine carga los modelos y el diccionario de caracteres. Devuelve
+// error si falta alguno de los archivos.
+func NewOCREngine() (*OCREngine, error) {
+	for _, path := range []string{OCRDetModel, OCRRecModel, OCRKeysFile} {
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := loadOCRKeys(OCRKeysFile)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := ort.NewSessionOptions()
+	if err != nil {
+		return nil, err
+	}
+	defer options.Destroy()
+	options.SetIntraOpNumThreads(max(1, runtime.NumCPU()/2))
+	options.SetInterOpNumThreads(1)
+
+	// Paso de tiempo y clases: de la salida del modelo si son fijas, si no
+	// lo que corresponde a un CRNN que reduce el ancho 8 veces.
+	steps, classes := ocrRecWidth/8, len(keys)+2
+	if _, outputs, err := ort.GetInputOutputInfo(OCRRecModel); err == nil && len(outputs) == 1 {
+		if dims := outputs[0].Dimensions; len(dims) == 3 {
+			if dims[1] > 0 {
+				steps = int(dims[1])
+			}
+			if dims[2] > 0 {
+				classes = int(dims[2])
+			}
+		}
+	}
+
+	det, err := newOCRSession(OCRDetModel,
+		ort.NewShape(1, 3, ocrDetSize, ocrDetSize), ort.NewShape(1, 1, ocrDetSize, ocrDetSize), options)
+	if err != nil {
+		return nil, fmt.Errorf("modelo de detección: %w", err)
+	}
+	rec, err := newOCRSession(OCRRecModel,
+		ort.NewShape(1, 3, ocrRecHeight, ocrRecWidth), ort.NewShape(1, int64(steps), int64(classes)), options)
+	if err != nil {
+		det.Destroy()
+		return nil, fmt.Errorf("modelo de reconocimiento: %w", err)
+	}
+
+	return &OCREngine{
+		det:     det,
+		rec:     rec,
+		keys:    keys,
+		steps:   steps,
+		classes: classes,
+		usage:   make(map[string][]ocrUsage),
+	}, nil
+}
+
+// loadOCRKeys lee el diccionario (un carácter por línea). El índice 0 de la
+// salida es el blanco de CTC y el último, si sobra, el espacio.
+func loadOCRKeys(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var keys []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		keys = append(keys, strings.TrimRight(scanner.Text(), "\r\n"))
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("diccionario de OCR vacío")
+	}
+	return keys, scanner.Err()
+}
+
+func (e *OCREngine) Close() {
+	e.det.Destroy()
+	e.rec.Destroy()
+}
+
+// allow indica si al servidor le queda presupuesto de CPU en el último minuto.
+func (e *OCREngine) allow(guildID string) bool {
+	e.usageMu.Lock()
+	defer e.usageMu.Unlock()
+
+	var spent time.Duration
+	kept := e.usage[guildID][:0]
+	for _, u := range e.usage[guildID] {
+		if time.Since(u.at) < time.Minute {
+			kept = append(kept, u)
+			spent += u.elapsed
+		}
+	}
+	e.usage[guildID] = kept
+	return spent < ocrGuildBudget
+}
+
+func (e *OCREngine) record(guildID string, elapsed time.Duration) {
+	e.usageMu.Lock()
+	e.usage[guildID] = append(e.usage[guildID], ocrUsage{at: time.Now(), elapsed: elapsed})
+	e.usageMu.Unlock()
+}
+
+// Extract devuelve el texto de la imagen, una línea por caja detectada, de
+// arriba hacia abajo y de izquierda a derecha. El presupuesto del servidor y
+// el timeout se cuentan desde que se obtiene el motor, no desde la cola.
+func (e *OCREngine) Extract(guildID string, img image.Image, timeout time.Duration) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.allow(guildID) {
+		return "", errOCRBudget
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() { e.record(guildID, time.Since(start)) }()
+
+	boxes, err := e.detect(img)
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, box := range boxes {
+		if ctx.Err() != nil {
+			break
+		}
+		text, err := e.recognize(img, box)
+		if err != nil {
+			return strings.Join(lines, "\n"), err
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (e *OCREngine) detect(img image.Image) ([]image.Rectangle, error) {
+	b := img.Bounds()
+	fillTensor(e.det.input.GetData(), resizeImage(img, ocrDetSize, ocrDetSize), ocrDetSize,
+		[3]float32{0.485, 0.456, 0.406}, [3]float32{0.229, 0.224, 0.225})
+	if err := e.det.session.Run(); err != nil {
+		return nil, err
+	}
+
+	scaleX := float64(b.Dx()) / ocrDetSize
+	scaleY := float64(b.Dy()) / ocrDetSize
+
+	var boxes []image.Rectangle
+	for _, r := range textRegions(e.det.output.GetData(), ocrDetSize) {
+		// Expande la región como el "unclip" de DB: área * 1.5 / perímetro
+		w, h := r.Dx(), r.Dy()
+		d := int(float64(w*h) * 1.5 / float64(2*(w+h)))
+		r = image.Rect(
+			int(float64(r.Min.X-d)*scaleX), int(float64(r.Min.Y-d)*scaleY),
+			int(float64(r.Max.X+d)*scaleX), int(float64(r.Max.Y+d)*scaleY),
+		).Add(b.Min).Intersect(b)
+		if r.Dx() >= 8 && r.Dy() >= 6 {
+			boxes = append(boxes, r)
+		}
+	}
+
+	sort.Slice(boxes, func(i, j int) bool {
+		if abs(boxes[i].Min.Y-boxes[j].Min.Y) > boxes[i].Dy()/2 {
+			return boxes[i].Min.Y < boxes[j].Min.Y
+		}
+		return boxes[i].Min.X < boxes[j].Min.X
+	})
+	if len(boxes) > ocrMaxBoxes {
+		boxes = boxes[:ocrMaxBoxes]
+	}
+	return boxes, nil
+}
+
+// textRegions binariza el mapa de probabilidad y devuelve el rectángulo de
+// cada componente conexa.
+func textRegions(prob []float32, width int) []image.Rectangle {
+	seen := make([]bool, len(prob))
+	var regions []image.Rectangle
+	var stack []int
+
+	for start := range prob {
+		if seen[start] || prob[start] < ocrBoxThresh {
+			continue
+		}
+		r := image.Rect(start%width, start/width, start%width+1, start/width+1)
+		size := 0
+		stack = append(stack[:0], start)
+		seen[start] = true
+		for len(stack) > 0 {
+			p := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			size++
+			x, y := p%width, p/width
+			r = r.Union(image.Rect(x, y, x+1, y+1))
+			for _, n := range [4]int{p - 1, p + 1, p - width, p + width} {
+				if n < 0 || n >= len(prob) || seen[n] || prob[n] < ocrBoxThresh {
+					continue
+				}
+				// Sin saltar de un borde de fila al otro
+				if (n == p-1 && x == 0) || (n == p+1 && x == width-1) {
+					continue
+				}
+				seen[n] = true
+				stack = append(stack, n)
+			}
+		}
+		if size >= 16 && r.Dy() >= 3 {
+			regions = append(regions, r)
+		}
+	}
+	return regions
+}
+
+func (e *OCREngine) recognize(img image.Image, box image.Rectangle) (string, error) {
+	crop := image.NewRGBA(image.Rect(0, 0, box.Dx(), box.Dy()))
+	draw.Draw(crop, crop.Bounds(), img, box.Min, draw.Src)
+
+	// Alto fijo conservando la proporción; el resto del ancho queda en cero
+	w := min(ocrRecWidth, max(1, box.Dx()*ocrRecHeight/box.Dy()))
+	resized := resizeImage(crop, w, ocrRecHeight)
+
+	data := e.rec.input.GetData()
+	clear(data)
+	plane := ocrRecHeight * ocrRecWidth
+	for y := 0; y < ocrRecHeight; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, _ := resized.At(x, y).RGBA()
+			idx := y*ocrRecWidth + x
+			data[idx] = (float32(r>>8)/255 - 0.5) / 0.5
+			data[plane+idx] = (float32(g>>8)/255 - 0.5) / 0.5
+			data[2*plane+idx] = (float32(b>>8)/255 - 0.5) / 0.5
+		}
+	}
+
+	if err := e.rec.session.Run(); err != nil {
+		return "", err
+	}
+	return e.decodeCTC(e.rec.output.GetData()), nil
+}
+
+// decodeCTC toma la clase más probable en cada paso y colapsa repetidos y blancos.
+func (e *OCREngine) decodeCTC(out []float32) string {
+	var b strings.Builder
+	prev := -1
+	for t := 0; t < e.steps; t++ {
+		row := out[t*e.classes : (t+1)*e.classes]
+		best := 0
+		for c := range row {
+			if row[c] > row[best] {
+				best = c
+			}
+		}
+		if best != 0 && best != prev {
+			switch {
+			case best-1 < len(e.keys):
+				b.WriteString(e.keys[best-1])
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		prev = best
+	}
+	return b.String()
+}
+
+func fillTensor(data []float32, img image.Image, size int, mean, std [3]float32) {
+	plane := size * size
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			idx := y*size + x
+			data[idx] = (float32(r>>8)/255 - mean[0]) / std[0]
+			data[plane+idx] = (float32(g>>8)/255 - mean[1]) / std[1]
+			data[2*plane+idx] = (float32(b>>8)/255 - mean[2]) / std[2]
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// LoadOCR carga los modelos de OCR; si no están, el OCR queda desactivado.
+func (m *Manager) LoadOCR() {
+	engine, err := NewOCREngine()
+	if err != nil {
+		fmt.Printf("OCR desactivado: %v\n", err)
+		return
+	}
+	m.OCR = engine
+	fmt.Println("Modelos de OCR cargados")
+}
+
+func (m *Manager) SetOCR(guildID string, enabled bool) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	m.GuildConfig[guildID].OCR = enabled
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+func (m *Manager) IsOCREnabled(guildID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok {
+		return cfg.OCR
+	}
+	return false
+}
+
+// checkOCR pasa el texto de la imagen por los mismos filtros que el de los
+// mensajes, si el servidor lo activó y le queda presupuesto.
+func (m *Manager) checkOCR(guildID string, img image.Image) *Detection {
+	if m.OCR == nil || !m.IsOCREnabled(guildID) {
+		return nil
+	}
+	text, err := m.OCR.Extract(guildID, img, ocrImageBudget)
+	if errors.Is(err, errOCRBudget) {
+		fmt.Printf("Presupuesto de OCR agotado en %s\n", guildID)
+		return nil
+	}
+	if err != nil {
+		fmt.Printf("Error en OCR: %v\n", err)
+	}
+	det := m.detectText(guildID, text)
+	if det == nil {
+		return nil
+	}
+	det.Detail = fmt.Sprintf("En imagen (OCR).\n%s\nTexto leído: `%s`", det.Detail, truncate(strings.ReplaceAll(text, "`", ""), 300))
+	return det
+}
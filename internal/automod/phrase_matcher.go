@@ -0,0 +1,265 @@
+package automod
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const numberToken = "$number"
+
+// numberWord identifica el comodín $number dentro de una frase.
+const numberWord = -1
+
+type PhraseMatch struct {
+	Phrase string
+	Text   string
+}
+
+// PhraseMatcher busca todas las frases de una sola pasada (Aho-Corasick)
+// sobre el texto compactado a letras y dígitos, por lo que tolera cualquier
+// separador entre caracteres. Las palabras de cada frase pueden aparecer en
+// cualquier orden.
+type PhraseMatcher struct {
+	phrases []compiledPhrase
+	words   []int // largo en runas de cada palabra
+	nodes   []acNode
+}
+
+type compiledPhrase struct {
+	text  string
+	words []int
+}
+
+type acNode struct {
+	next map[rune]int
+	fail int
+	out  []int
+}
+
+type compactText struct {
+	runes []rune
+	start []int // offset en bytes del texto original
+	end   []int
+}
+
+type occurrence struct {
+	word int
+	end  int
+}
+
+func NewPhraseMatcher(phrases []string) *PhraseMatcher {
+	pm := &PhraseMatcher{nodes: []acNode{{next: map[rune]int{}}}}
+	wordIDs := make(map[string]int)
+	seen := make(map[string]bool)
+
+	for _, phrase := range phrases {
+		key := strings.ToLower(strings.Join(strings.Fields(phrase), " "))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		cp := compiledPhrase{text: phrase}
+		for _, token := range strings.Fields(phrase) {
+			if strings.EqualFold(token, numberToken) {
+				cp.words = append(cp.words, numberWord)
+				continue
+			}
+
+			word := string(compact(token).runes)
+			if word == "" {
+				continue
+			}
+			id, ok := wordIDs[word]
+			if !ok {
+				id = len(pm.words)
+				wordIDs[word] = id
+				pm.words = append(pm.words, utf8.RuneCountInString(word))
+				pm.insert(word, id)
+			}
+			cp.words = append(cp.words, id)
+		}
+
+		if len(cp.words) > 0 {
+			pm.phrases = append(pm.phrases, cp)
+		}
+	}
+
+	pm.build()
+	return pm
+}
+
+func (pm *PhraseMatcher) insert(word string, id int) {
+	cur := 0
+	for _, r := range word {
+		nxt, ok := pm.nodes[cur].next[r]
+		if !ok {
+			nxt = len(pm.nodes)
+			pm.nodes = append(pm.nodes, acNode{next: map[rune]int{}})
+			pm.nodes[cur].next[r] = nxt
+		}
+		cur = nxt
+	}
+	pm.nodes[cur].out = append(pm.nodes[cur].out, id)
+}
+
+func (pm *PhraseMatcher) build() {
+	var queue []int
+	for _, child := range pm.nodes[0].next {
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		for r, child := range pm.nodes[cur].next {
+			f := pm.nodes[cur].fail
+			for f != 0 {
+				if _, ok := pm.nodes[f].next[r]; ok {
+					break
+				}
+				f = pm.nodes[f].fail
+			}
+			if nxt, ok := pm.nodes[f].next[r]; ok && nxt != child {
+				f = nxt
+			}
+			pm.nodes[child].fail = f
+			pm.nodes[child].out = append(pm.nodes[child].out, pm.nodes[f].out...)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// compact deja solo letras y dígitos en minúscula, recordando su posición original.
+func compact(text string) compactText {
+	var ct compactText
+	for i, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		ct.runes = append(ct.runes, unicode.ToLower(r))
+		ct.start = append(ct.start, i)
+		ct.end = append(ct.end, i+utf8.RuneLen(r))
+	}
+	return ct
+}
+
+// Find busca la primera frase del texto. window es la cantidad de letras o
+// dígitos extra permitidos entre las palabras de una frase; con 0 tienen que
+// ir pegadas (solo separadores).
+func (pm *PhraseMatcher) Find(text string, window int) (PhraseMatch, bool) {
+	if len(pm.phrases) == 0 {
+		return PhraseMatch{}, false
+	}
+
+	ct := compact(text)
+	occ := make(map[int][]occurrence)
+
+	cur := 0
+	for i, r := range ct.runes {
+		for cur != 0 {
+			if _, ok := pm.nodes[cur].next[r]; ok {
+				break
+			}
+			cur = pm.nodes[cur].fail
+		}
+		if nxt, ok := pm.nodes[cur].next[r]; ok {
+			cur = nxt
+		}
+		for _, id := range pm.nodes[cur].out {
+			start := i - pm.words[id] + 1
+			occ[start] = append(occ[start], occurrence{word: id, end: i + 1})
+		}
+	}
+
+	// $number: cualquier tramo de dígitos desde el inicio del número, con sufijo
+	// k/m/b opcional. Se prueban primero los más largos.
+	for i := 0; i < len(ct.runes); i++ {
+		if !unicode.IsDigit(ct.runes[i]) || (i > 0 && unicode.IsDigit(ct.runes[i-1])) {
+			continue
+		}
+		j := i
+		for j < len(ct.runes) && unicode.IsDigit(ct.runes[j]) {
+			j++
+		}
+		if j < len(ct.runes) && strings.ContainsRune("kmb", ct.runes[j]) {
+			occ[i] = append(occ[i], occurrence{word: numberWord, end: j + 1})
+		}
+		for end := j; end > i; end-- {
+			occ[i] = append(occ[i], occurrence{word: numberWord, end: end})
+		}
+	}
+
+	for _, phrase := range pm.phrases {
+		for start := 0; start < len(ct.runes); start++ {
+			if end, ok := pm.matchFrom(text, &ct, occ, phrase.words, start, window); ok {
+				return PhraseMatch{
+					Phrase: phrase.text,
+					Text:   text[ct.start[start]:ct.end[end-1]],
+				}, true
+			}
+		}
+	}
+
+	return PhraseMatch{}, false
+}
+
+// matchFrom intenta cubrir todas las palabras de la frase, en cualquier orden,
+// empezando exactamente en start. Devuelve el final (exclusivo) en runas compactas.
+func (pm *PhraseMatcher) matchFrom(text string, ct *compactText, occ map[int][]occurrence, words []int, start, window int) (int, bool) {
+	used := make([]bool, len(words))
+
+	var walk func(pos, left, slack int) (int, bool)
+	walk = func(pos, left, slack int) (int, bool) {
+		if left == 0 {
+			if isWordBoundary(text, ct.start[start], true) && isWordBoundary(text, ct.end[pos-1], false) {
+				return pos, true
+			}
+			return 0, false
+		}
+
+		maxSkip := slack
+		if pos == start {
+			maxSkip = 0
+		}
+		for skip := 0; skip <= maxSkip; skip++ {
+			for _, o := range occ[pos+skip] {
+				tried := false
+				for i, w := range words {
+					if used[i] || w != o.word || tried {
+						continue
+					}
+					tried = true
+					used[i] = true
+					end, ok := walk(o.end, left-1, slack-skip)
+					used[i] = false
+					if ok {
+						return end, true
+					}
+				}
+			}
+		}
+		return 0, false
+	}
+
+	return walk(start, len(words), window)
+}
+
+// isWordBoundary replica el \b de la regex: el carácter vecino no puede ser de palabra.
+func isWordBoundary(text string, offset int, before bool) bool {
+	var r rune
+	if before {
+		if offset == 0 {
+			return true
+		}
+		r, _ = utf8.DecodeLastRuneInString(text[:offset])
+	} else {
+		if offset >= len(text) {
+			return true
+		}
+		r, _ = utf8.DecodeRuneInString(text[offset:])
+	}
+	return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || unicode.Is(unicode.Mn, r))
+}
@@ -0,0 +1,135 @@
+package automod
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dlclark/regexp2"
+)
+
+// phraseRegex y wordPermutations reproducen el enfoque anterior (una regex
+// por cada orden posible de las palabras) solo para comparar.
+func phraseRegex(phrase string) *regexp2.Regexp {
+	sep := `[\s\W_]*`
+	numberPattern := `\$?\s*(?:\d{1,3}(?:[.,]\d{3})+|\d+(?:[.,]\d+)?)(?:\s*[kKmMbB])?`
+
+	var parts []string
+	for _, token := range strings.Fields(phrase) {
+		if strings.EqualFold(token, numberToken) {
+			parts = append(parts, numberPattern)
+			continue
+		}
+		var chars []string
+		for _, ch := range token {
+			chars = append(chars, regexp2.Escape(string(ch)))
+		}
+		parts = append(parts, strings.Join(chars, sep))
+	}
+	return regexp2.MustCompile(`\b(?:`+strings.Join(parts, sep)+`)\b`, regexp2.IgnoreCase)
+}
+
+func wordPermutations(s string) []string {
+	words := strings.Fields(s)
+	var result []string
+	var backtrack func(path []string, used []bool)
+	backtrack = func(path []string, used []bool) {
+		if len(path) == len(words) {
+			result = append(result, strings.Join(path, " "))
+			return
+		}
+		seen := make(map[string]bool)
+		for i := range words {
+			if used[i] || seen[words[i]] {
+				continue
+			}
+			seen[words[i]] = true
+			used[i] = true
+			backtrack(append(path, words[i]), used)
+			used[i] = false
+		}
+	}
+	backtrack(nil, make([]bool, len(words)))
+	return result
+}
+
+func legacyPhraseFilters() []*regexp2.Regexp {
+	var filters []*regexp2.Regexp
+	seen := make(map[string]bool)
+	for _, p := range BasePhrases {
+		for _, perm := range wordPermutations(p) {
+			if !seen[perm] {
+				seen[perm] = true
+				filters = append(filters, phraseRegex(perm))
+			}
+		}
+	}
+	return filters
+}
+
+var phraseSamples = []string{
+	"hey did you see the new update, looks good",
+	"FREE.BONUS.CODE inside, hurry up",
+	"code bonus free for everyone who joins",
+	"receive your $2,500 now at our site",
+	"receive your 300k today",
+	"c-l-a-i-m y o u r n.i.t.r.o here",
+	"anyone up for some ranked games tonight?",
+	"the casino scene in that movie was great",
+	"crypto   casino with instant withdrawals",
+	"this message will be deleted in one hour lol",
+	"deleted one hour ago, check the pins",
+	"i got a special promo code from the store",
+	"lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua",
+}
+
+func TestPhraseMatcherMatchesRegex(t *testing.T) {
+	pm := NewPhraseMatcher(BasePhrases)
+	filters := legacyPhraseFilters()
+	for _, text := range phraseSamples {
+		want := false
+		for _, re := range filters {
+			if ok, _ := re.MatchString(text); ok {
+				want = true
+				break
+			}
+		}
+		if _, got := pm.Find(text, 0); got != want {
+			t.Errorf("Find(%q) = %v, la regex da %v", text, got, want)
+		}
+	}
+}
+
+func TestPhraseMatcherWindow(t *testing.T) {
+	pm := NewPhraseMatcher([]string{"claim your nitro"})
+	text := "claim all your free nitro"
+	if _, ok := pm.Find(text, 0); ok {
+		t.Fatalf("Find(%q, 0) no debería coincidir", text)
+	}
+	if _, ok := pm.Find(text, 8); !ok {
+		t.Fatalf("Find(%q, 8) debería coincidir", text)
+	}
+}
+
+func BenchmarkPhraseMatcher(b *testing.B) {
+	pm := NewPhraseMatcher(BasePhrases)
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, text := range phraseSamples {
+			pm.Find(text, 0)
+		}
+	}
+}
+
+func BenchmarkPhraseRegex(b *testing.B) {
+	filters := legacyPhraseFilters()
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, text := range phraseSamples {
+			for _, re := range filters {
+				if ok, _ := re.MatchString(text); ok {
+					break
+				}
+			}
+		}
+	}
+}
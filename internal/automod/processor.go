@@ -30,7 +30,11 @@ type CLIPScanner struct {
 	session *AdvancedSessionWrapper
 }
 
+// AdvancedSessionWrapper comparte un solo par de tensores entre todas las
+// llamadas, así que Run las corre de a una (mensajes, avatares y la carga de
+// imágenes llegan en paralelo).
 type AdvancedSessionWrapper struct {
+	mu           sync.Mutex
 	session      *ort.AdvancedSession
 	inputTensor  *ort.Tensor[float32]
 	outputTensor *ort.Tensor[float32]
@@ -49,15 +53,18 @@ func SessionWrapper() *AdvancedSessionWrapper {
 
 func (s *AdvancedSessionWrapper) Run(img image.Image) []float32 {
 	data := preprocess(img)
-	copy(s.inputTensor.GetData(), data)
 
+	s.mu.Lock()
+	copy(s.inputTensor.GetData(), data)
 	if err := s.session.Run(); err != nil {
+		s.mu.Unlock()
 		panic(err)
 	}
-
 	embedding := s.outputTensor.GetData()
 	out := make([]float32, len(embedding))
 	copy(out, embedding)
+	s.mu.Unlock()
+
 	normalize(out)
 	return out
 }
@@ -79,6 +86,11 @@ func (c *CLIPScanner) Close() {
 	c.session.Close()
 }
 
+// Embed devuelve el embedding normalizado de la imagen.
+func (c *CLIPScanner) Embed(img image.Image) []float32 {
+	return c.session.Run(img)
+}
+
 func (c *CLIPScanner) LoadScamImages(dir string) error {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
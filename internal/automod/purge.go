@@ -0,0 +1,87 @@
+package automod
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// PurgeableRules son las razones de sanción que admiten purga configurable.
+var PurgeableRules = []string{
+	"Spam Filter",
+	"Scam Phrase Filter",
+	"Mensaje Duplicado",
+	"Enlace Enmascarado",
+	"Dominio Bloqueado",
+	"Dominio Sospechoso",
+	"Invitación",
+	"Adjunto Peligroso",
+	"Mass Mention",
+	"Spam",
+	"Imagen Scam",
+	"QR Sospechoso",
+	"Contenido NSFW",
+}
+
+func (m *Manager) SetPurgeRule(guildID, rule string, minutes int) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	cfg := m.GuildConfig[guildID]
+	if minutes <= 0 {
+		delete(cfg.PurgeRules, rule)
+	} else {
+		if cfg.PurgeRules == nil {
+			cfg.PurgeRules = make(map[string]int)
+		}
+		cfg.PurgeRules[rule] = minutes
+	}
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+func (m *Manager) purgeWindow(guildID, reason string) time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cfg, ok := m.GuildConfig[guildID]
+	if !ok {
+		return 0
+	}
+	return time.Duration(cfg.PurgeRules[strings.TrimSuffix(reason, editedSuffix)]) * time.Minute
+}
+
+// PurgeRecentMessages borra los mensajes del usuario de la última ventana en
+// todos los canales e hilos, usando la caché local en vez del historial.
+// Devuelve cuántos se borraron y en qué canales.
+func (m *Manager) PurgeRecentMessages(s *discordgo.Session, guildID, userID string, window time.Duration, exceptID string) (int, []string) {
+	byChannel := make(map[string][]string)
+	for _, msg := range m.Messages.UserMessages(guildID, userID, time.Now().Add(-window)) {
+		if msg.ID == exceptID {
+			continue
+		}
+		byChannel[msg.ChannelID] = append(byChannel[msg.ChannelID], msg.ID)
+	}
+
+	count := 0
+	var channels []string
+	for channelID, ids := range byChannel {
+		if n := m.deleteMessages(s, channelID, ids); n > 0 {
+			count += n
+			channels = append(channels, channelID)
+		}
+	}
+	sort.Strings(channels)
+	return count, channels
+}
+
+func formatPurge(count int, channels []string) string {
+	mentions := make([]string, len(channels))
+	for i, id := range channels {
+		mentions[i] = fmt.Sprintf("<#%s>", id)
+	}
+	return fmt.Sprintf("Purgados: %d mensajes en %s", count, strings.Join(mentions, ", "))
+}
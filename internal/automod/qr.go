@@ -0,0 +1,110 @@
+package automod
+
+import (
+	"fmt"
+	"image"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/makiuchi-d/gozxing"
+	multiqr "github.com/makiuchi-d/gozxing/multi/qrcode"
+)
+
+// Lado máximo con el que se busca el QR; las imágenes más grandes se reducen
+const qrMaxSide = 1600
+
+// DecodeQR busca todos los códigos QR de la imagen y devuelve su contenido.
+func DecodeQR(img image.Image) []string {
+	b := img.Bounds()
+	if side := max(b.Dx(), b.Dy()); side > qrMaxSide {
+		img = resizeImage(img, b.Dx()*qrMaxSide/side, b.Dy()*qrMaxSide/side)
+	}
+
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return nil
+	}
+	hints := map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	}
+	results, err := multiqr.NewQRCodeMultiReader().DecodeMultiple(bmp, hints)
+	if err != nil {
+		return nil
+	}
+
+	payloads := make([]string, 0, len(results))
+	for _, r := range results {
+		payloads = append(payloads, r.GetText())
+	}
+	return payloads
+}
+
+// qrLinks extrae los links del contenido de un QR, que a veces viene sin
+// esquema. Sin esquema solo cuenta si empieza por un dominio real, para no
+// tomar como link seriales, claves de Wi-Fi o tokens.
+func qrLinks(payload string) []Link {
+	links := ExtractLinks(payload, nil)
+	if len(links) > 0 || strings.Contains(payload, "://") || strings.ContainsAny(payload, " \n") {
+		return links
+	}
+	links = ExtractLinks("https://"+payload, nil)
+	if len(links) == 0 || !looksLikeHost(links[0].Host) {
+		return nil
+	}
+	return links
+}
+
+// isDiscordLoginQR reconoce el QR de inicio de sesión de Discord, que entrega
+// la cuenta a quien lo generó.
+func isDiscordLoginQR(link Link) bool {
+	u, err := url.Parse(link.URL)
+	if err != nil {
+		return false
+	}
+	return brandOf(NormalizeHost(link.Host)) == "discord" && strings.HasPrefix(u.Path, "/ra/")
+}
+
+// checkQR decodifica los QR de la imagen y sanciona los que llevan a un
+// dominio bloqueado o desconocido, a una invitación no permitida o al login de Discord.
+func (m *Manager) checkQR(s *discordgo.Session, guildID string, img image.Image) *Detection {
+	for _, payload := range DecodeQR(img) {
+		shown := fmt.Sprintf("Contenido del QR: `%s`", truncate(strings.ReplaceAll(payload, "`", ""), 300))
+
+		if det := m.checkInvites(s, guildID, payload); det != nil {
+			det.Reason = "QR Sospechoso"
+			det.Detail += "\n" + shown
+			return det
+		}
+
+		for _, link := range qrLinks(payload) {
+			if isDiscordLoginQR(link) {
+				return &Detection{
+					Reason: "QR Sospechoso",
+					Detail: "QR de inicio de sesión de Discord (robo de cuenta).\n" + shown,
+					Mute:   7 * 24 * time.Hour,
+				}
+			}
+			if m.Domains == nil {
+				continue
+			}
+			v := m.Domains.Check(link.Host)
+			switch {
+			case v.Blocked:
+				return &Detection{
+					Reason: "QR Sospechoso",
+					Detail: fmt.Sprintf("%s\n%s", v.Reason, shown),
+					Mute:   7 * 24 * time.Hour,
+				}
+			case !v.Allowed:
+				return &Detection{
+					Reason: "QR Sospechoso",
+					Detail: fmt.Sprintf("QR hacia un dominio desconocido (`%s`).\n%s", v.Host, shown),
+					Mute:   7 * 24 * time.Hour,
+				}
+			}
+		}
+	}
+	return nil
+}
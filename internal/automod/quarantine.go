@@ -0,0 +1,284 @@
+package automod
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	errNoQuarantineRole = errors.New("no hay rol de cuarentena configurado")
+	errNotQuarantined   = errors.New("el usuario no está en cuarentena")
+)
+
+// QuarantineRecord guarda los roles que tenía el usuario antes de la
+// cuarentena. Until en cero significa hasta que un moderador lo libere.
+type QuarantineRecord struct {
+	Roles  []string  `json:"roles"`
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason"`
+	By     string    `json:"by"`
+}
+
+// QuarantineStore persiste las cuarentenas por servidor y usuario.
+type QuarantineStore struct {
+	mu     sync.Mutex
+	path   string
+	active map[string]map[string]*QuarantineRecord
+}
+
+func NewQuarantineStore(path string) *QuarantineStore {
+	q := &QuarantineStore{
+		path:   path,
+		active: make(map[string]map[string]*QuarantineRecord),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error leyendo cuarentenas en %s: %v\n", path, err)
+		}
+		return q
+	}
+	if err := json.Unmarshal(data, &q.active); err != nil {
+		fmt.Printf("Error deserializando cuarentenas: %v\n", err)
+	}
+	return q
+}
+
+// saveLocked debe llamarse con q.mu tomado.
+func (q *QuarantineStore) saveLocked() {
+	data, err := json.MarshalIndent(q.active, "", "  ")
+	if err != nil {
+		fmt.Printf("Error serializando cuarentenas: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(q.path, data, 0644); err != nil {
+		fmt.Printf("Error guardando cuarentenas en %s: %v\n", q.path, err)
+	}
+}
+
+func (q *QuarantineStore) Get(guildID, userID string) (QuarantineRecord, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	rec, ok := q.active[guildID][userID]
+	if !ok {
+		return QuarantineRecord{}, false
+	}
+	return *rec, true
+}
+
+func (m *Manager) SetQuarantineMode(guildID string, enabled bool) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	m.GuildConfig[guildID].QuarantineMode = enabled
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+// IsQuarantineMode indica si las sanciones usan cuarentena en vez de aislamiento.
+func (m *Manager) IsQuarantineMode(guildID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok {
+		return cfg.QuarantineMode && cfg.QuarantineRoleID != ""
+	}
+	return false
+}
+
+func (m *Manager) SetQuarantineChannel(guildID, channelID string) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	m.GuildConfig[guildID].QuarantineChannelID = channelID
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+func (m *Manager) GetQuarantineChannel(guildID string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok {
+		return cfg.QuarantineChannelID
+	}
+	return ""
+}
+
+// managedRoles devuelve los roles que Discord no deja quitar ni poner a mano
+// (booster, integraciones).
+func managedRoles(s *discordgo.Session, guildID string) map[string]bool {
+	roles, err := s.GuildRoles(guildID)
+	if err != nil {
+		fmt.Printf("Error listando roles de %s: %v\n", guildID, err)
+		return nil
+	}
+	managed := make(map[string]bool)
+	for _, r := range roles {
+		if r.Managed {
+			managed[r.ID] = true
+		}
+	}
+	return managed
+}
+
+// QuarantineMember quita los roles del usuario, guardándolos, y le asigna el
+// rol de cuarentena. Con duration en cero dura hasta /release. Si ya estaba en
+// cuarentena se conservan los roles guardados la primera vez y el final más
+// tardío, para que una sanción corta no acorte una indefinida.
+func (m *Manager) QuarantineMember(s *discordgo.Session, guildID, userID, reason, by string, duration time.Duration) error {
+	roleID := m.GetQuarantineRole(guildID)
+	if roleID == "" {
+		return errNoQuarantineRole
+	}
+	member, err := s.GuildMember(guildID, userID)
+	if err != nil {
+		return err
+	}
+	managed := managedRoles(s, guildID)
+
+	q := m.Quarantines
+	q.mu.Lock()
+	rec, ok := q.active[guildID][userID]
+	if !ok {
+		var saved []string
+		for _, r := range member.Roles {
+			if r != roleID && !managed[r] {
+				saved = append(saved, r)
+			}
+		}
+		rec = &QuarantineRecord{Roles: saved, Since: time.Now()}
+		if q.active[guildID] == nil {
+			q.active[guildID] = make(map[string]*QuarantineRecord)
+		}
+		q.active[guildID][userID] = rec
+	}
+	rec.Reason, rec.By = reason, by
+	var until time.Time
+	if duration > 0 {
+		until = time.Now().Add(duration)
+	}
+	if !ok || (!rec.Until.IsZero() && (until.IsZero() || until.After(rec.Until))) {
+		rec.Until = until
+	}
+	until = rec.Until
+	// Se guarda antes de tocar los roles para no perderlos si algo falla
+	q.saveLocked()
+	q.mu.Unlock()
+
+	roles := []string{roleID}
+	for _, r := range member.Roles {
+		if managed[r] {
+			roles = append(roles, r)
+		}
+	}
+	_, err = s.GuildMemberEdit(guildID, userID, &discordgo.GuildMemberParams{Roles: &roles}, discordgo.WithAuditLogReason("Cuarentena: "+reason))
+	if err != nil {
+		if !ok {
+			q.mu.Lock()
+			delete(q.active[guildID], userID)
+			q.saveLocked()
+			q.mu.Unlock()
+		}
+		return err
+	}
+
+	if until.IsZero() {
+		m.Scheduler.CancelFor(guildID, JobRelease, userID)
+	} else {
+		m.Scheduler.Schedule(Job{Type: JobRelease, GuildID: guildID, UserID: userID, At: until, By: by})
+	}
+
+	if channelID := m.GetQuarantineChannel(guildID); channelID != "" {
+		notice := fmt.Sprintf("<@%s> fuiste puesto en cuarentena. Motivo: %s\nUn moderador revisará tu caso en este canal.", userID, reason)
+		if !until.IsZero() {
+			notice += fmt.Sprintf(" Se levanta sola <t:%d:R>.", until.Unix())
+		}
+		s.ChannelMessageSend(channelID, notice)
+	}
+	return nil
+}
+
+// ReleaseMember quita el rol de cuarentena y devuelve exactamente los roles
+// guardados que todavía existen. Devuelve cuántos restauró.
+func (m *Manager) ReleaseMember(s *discordgo.Session, guildID, userID, by string) (int, error) {
+	q := m.Quarantines
+	q.mu.Lock()
+	rec, ok := q.active[guildID][userID]
+	q.mu.Unlock()
+	if !ok {
+		return 0, errNotQuarantined
+	}
+
+	member, err := s.GuildMember(guildID, userID)
+	if err != nil && !isNotFound(err) {
+		return 0, err
+	}
+	restored := 0
+	// Si ya no está en el servidor solo se olvida la cuarentena
+	if member != nil {
+		// Sin la lista de roles del servidor se intenta restaurar todo
+		var existing map[string]bool
+		if guildRoles, err := s.GuildRoles(guildID); err == nil {
+			existing = make(map[string]bool)
+			for _, r := range guildRoles {
+				existing[r.ID] = true
+			}
+		}
+		roleID := m.GetQuarantineRole(guildID)
+		roles := slices.DeleteFunc(slices.Clone(member.Roles), func(r string) bool { return r == roleID })
+		for _, r := range rec.Roles {
+			if (existing == nil || existing[r]) && !slices.Contains(roles, r) {
+				roles = append(roles, r)
+				restored++
+			}
+		}
+		_, err = s.GuildMemberEdit(guildID, userID, &discordgo.GuildMemberParams{Roles: &roles}, discordgo.WithAuditLogReason("Fin de cuarentena"))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	q.mu.Lock()
+	delete(q.active[guildID], userID)
+	if len(q.active[guildID]) == 0 {
+		delete(q.active, guildID)
+	}
+	q.saveLocked()
+	q.mu.Unlock()
+	m.Scheduler.CancelFor(guildID, JobRelease, userID)
+
+	m.LogEvent(s, guildID, &discordgo.MessageEmbed{
+		Title:       "🔓 Cuarentena Terminada",
+		Description: fmt.Sprintf("Usuario: <@%s>\nPor: %s\nDuración: %s\nRoles restaurados: %d", userID, by, time.Since(rec.Since).Round(time.Second), restored),
+		Color:       0x2ecc71,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	})
+	return restored, nil
+}
+
+// reapplyQuarantine vuelve a poner el rol de cuarentena a quien sale y vuelve
+// a entrar para sacárselo. Devuelve true solo si lo pudo poner.
+func (m *Manager) reapplyQuarantine(s *discordgo.Session, guildID, userID string) bool {
+	if _, ok := m.Quarantines.Get(guildID, userID); !ok {
+		return false
+	}
+	roleID := m.GetQuarantineRole(guildID)
+	if roleID == "" {
+		return false
+	}
+	if err := s.GuildMemberRoleAdd(guildID, userID, roleID); err != nil {
+		fmt.Printf("Error reaplicando cuarentena a %s: %v\n", userID, err)
+		// Sin el rol tiene que pasar por los controles de entrada como cualquiera
+		return false
+	}
+	return true
+}
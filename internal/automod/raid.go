@@ -0,0 +1,473 @@
+package automod
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/corona10/goimagehash"
+)
+
+type RaidConfig struct {
+	Enabled bool `json:"enabled"`
+	// Entradas dentro de la ventana que disparan el modo raid
+	Joins         int `json:"joins"`
+	WindowSeconds int `json:"window_seconds"`
+	// Cuentas más nuevas que esto cuentan como recién creadas
+	NewAccountDays int `json:"new_account_days"`
+	NewAccounts    int `json:"new_accounts"`
+	// Cuentas con nombre o avatar parecido entre sí
+	Similar int `json:"similar"`
+
+	RaiseVerification bool `json:"raise_verification"`
+	PauseInvites      bool `json:"pause_invites"`
+	// Bloquear todos los canales mientras dure el raid
+	Lockdown bool `json:"lockdown"`
+	// "timeout", "kick" o "none" para los que entran durante el raid
+	Action          string `json:"action"`
+	TimeoutHours    int    `json:"timeout_hours"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
+
+// DefaultRaidConfig viene desactivada: las acciones (verificación alta,
+// invitaciones pausadas, timeout a quienes entran) se activan recién con
+// /set raid-protection.
+var DefaultRaidConfig = RaidConfig{
+	Enabled:           false,
+	Joins:             10,
+	WindowSeconds:     30,
+	NewAccountDays:    7,
+	NewAccounts:       6,
+	Similar:           4,
+	RaiseVerification: true,
+	PauseInvites:      true,
+	Action:            "timeout",
+	TimeoutHours:      24,
+	DurationMinutes:   30,
+}
+
+const RaidEndButtonID = "raid_end"
+
+type joinRecord struct {
+	userID string
+	name   string
+	avatar *goimagehash.ImageHash
+	// El avatar coincide con la biblioteca de scams o de avatares maliciosos
+	badAvatar bool
+	created   time.Time
+	at        time.Time
+}
+
+// RaidState es lo necesario para deshacer el modo raid, incluso tras un reinicio.
+type RaidState struct {
+	Since            time.Time                    `json:"since"`
+	Until            time.Time                    `json:"until"`
+	Trigger          string                       `json:"trigger"`
+	PrevVerification *discordgo.VerificationLevel `json:"prev_verification,omitempty"`
+	PausedInvites    bool                         `json:"paused_invites"`
+	Lockdown         bool                         `json:"lockdown"`
+	Joiners          []string                     `json:"joiners"`
+}
+
+// RaidMonitor guarda las entradas recientes y los servidores en modo raid.
+type RaidMonitor struct {
+	mu     sync.Mutex
+	path   string
+	joins  map[string][]joinRecord
+	active map[string]*RaidState
+}
+
+func NewRaidMonitor(path string) *RaidMonitor {
+	r := &RaidMonitor{
+		path:   path,
+		joins:  make(map[string][]joinRecord),
+		active: make(map[string]*RaidState),
+	}
+	r.load()
+	return r
+}
+
+func (r *RaidMonitor) load() {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error leyendo estado de raids en %s: %v\n", r.path, err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &r.active); err != nil {
+		fmt.Printf("Error deserializando estado de raids: %v\n", err)
+	}
+}
+
+// saveLocked debe llamarse con r.mu tomado.
+func (r *RaidMonitor) saveLocked() {
+	data, err := json.MarshalIndent(r.active, "", "  ")
+	if err != nil {
+		fmt.Printf("Error serializando estado de raids: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(r.path, data, 0644); err != nil {
+		fmt.Printf("Error guardando estado de raids en %s: %v\n", r.path, err)
+	}
+}
+
+func (r *RaidMonitor) IsActive(guildID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.active[guildID]
+	return ok
+}
+
+// nameBase pliega confusables y quita dígitos y signos, para agrupar nombres
+// generados en serie (raider123, r4ider_456...).
+func nameBase(name string) string {
+	// El sufijo numérico es lo que cambia entre cuentas; el resto puede ser leet
+	name = strings.TrimRightFunc(name, func(r rune) bool {
+		return unicode.IsDigit(r) || unicode.IsPunct(r)
+	})
+	var b strings.Builder
+	for _, r := range Skeleton(name, DefaultLeetMap) {
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func similarNames(a, b string) bool {
+	if len(a) < 3 || len(b) < 3 {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	longest := max(len([]rune(a)), len([]rune(b)))
+	return longest >= 5 && 1-float64(levenshtein(a, b))/float64(longest) >= 0.8
+}
+
+// raidSignals cuenta, en la ventana, las entradas, las cuentas nuevas y las
+// que se parecen a otra por nombre o avatar. Un avatar conocido cuenta como
+// parecido aunque sea la única cuenta que lo usa.
+func raidSignals(joins []joinRecord, cfg RaidConfig) (total, newAccounts, similar int) {
+	maxAge := time.Duration(cfg.NewAccountDays) * 24 * time.Hour
+	for i, a := range joins {
+		total++
+		if time.Since(a.created) < maxAge {
+			newAccounts++
+		}
+		if a.badAvatar {
+			similar++
+			continue
+		}
+		for j, b := range joins {
+			if i == j {
+				continue
+			}
+			if similarNames(a.name, b.name) || sameAvatar(a.avatar, b.avatar) {
+				similar++
+				break
+			}
+		}
+	}
+	return
+}
+
+func (m *Manager) raidConfig(guildID string) RaidConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok && cfg.Raid != nil {
+		return *cfg.Raid
+	}
+	return DefaultRaidConfig
+}
+
+func (m *Manager) SetRaidProtection(guildID string, enabled bool) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	if m.GuildConfig[guildID].Raid == nil {
+		raid := DefaultRaidConfig
+		m.GuildConfig[guildID].Raid = &raid
+	}
+	m.GuildConfig[guildID].Raid.Enabled = enabled
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+// checkRaid registra la entrada y, si el servidor ya está en modo raid o las
+// señales superan los umbrales, sanciona a quienes entraron.
+func (m *Manager) checkRaid(s *discordgo.Session, ev *discordgo.GuildMemberAdd, avatar *avatarScan) {
+	cfg := m.raidConfig(ev.GuildID)
+	if !cfg.Enabled {
+		return
+	}
+
+	created, _ := discordgo.SnowflakeTimestamp(ev.User.ID)
+	rec := joinRecord{
+		userID:  ev.User.ID,
+		name:    nameBase(ev.User.Username),
+		created: created,
+		at:      time.Now(),
+	}
+	if avatar != nil {
+		rec.avatar = avatar.hash
+		rec.badAvatar = avatar.match != nil
+	}
+	window := time.Duration(cfg.WindowSeconds) * time.Second
+
+	r := m.Raids
+	r.mu.Lock()
+	if state, ok := r.active[ev.GuildID]; ok {
+		state.Joiners = append(state.Joiners, ev.User.ID)
+		r.saveLocked()
+		r.mu.Unlock()
+		m.actOnJoiner(s, ev.GuildID, ev.User.ID, cfg)
+		return
+	}
+
+	joins := r.joins[ev.GuildID]
+	kept := joins[:0]
+	for _, j := range joins {
+		if time.Since(j.at) < window {
+			kept = append(kept, j)
+		}
+	}
+	joins = append(kept, rec)
+	r.joins[ev.GuildID] = joins
+
+	total, newAccounts, similar := raidSignals(joins, cfg)
+	var triggers []string
+	if total >= cfg.Joins {
+		triggers = append(triggers, fmt.Sprintf("%d entradas en %ds", total, cfg.WindowSeconds))
+	}
+	if newAccounts >= cfg.NewAccounts {
+		triggers = append(triggers, fmt.Sprintf("%d cuentas de menos de %d días", newAccounts, cfg.NewAccountDays))
+	}
+	if similar >= cfg.Similar {
+		triggers = append(triggers, fmt.Sprintf("%d cuentas con nombre o avatar parecido", similar))
+	}
+	if len(triggers) == 0 {
+		r.mu.Unlock()
+		return
+	}
+
+	burst := make([]string, len(joins))
+	for i, j := range joins {
+		burst[i] = j.userID
+	}
+	delete(r.joins, ev.GuildID)
+	r.mu.Unlock()
+
+	m.StartRaid(s, ev.GuildID, strings.Join(triggers, ", "), burst)
+}
+
+func (m *Manager) actOnJoiner(s *discordgo.Session, guildID, userID string, cfg RaidConfig) {
+	var err error
+	switch cfg.Action {
+	case "timeout":
+		until := time.Now().Add(time.Duration(cfg.TimeoutHours) * time.Hour)
+		err = s.GuildMemberTimeout(guildID, userID, &until)
+	case "kick":
+		err = s.GuildMemberDeleteWithReason(guildID, userID, "Modo raid")
+	}
+	if err != nil {
+		fmt.Printf("Error sancionando a %s durante el raid: %v\n", userID, err)
+	}
+}
+
+// StartRaid activa el modo raid: sube la verificación, pausa las invitaciones,
+// sanciona la ráfaga de entradas y publica un reporte con botón para terminarlo.
+func (m *Manager) StartRaid(s *discordgo.Session, guildID, trigger string, burst []string) {
+	cfg := m.raidConfig(guildID)
+
+	r := m.Raids
+	r.mu.Lock()
+	if _, ok := r.active[guildID]; ok {
+		r.mu.Unlock()
+		return
+	}
+	state := &RaidState{
+		Since:   time.Now(),
+		Until:   time.Now().Add(time.Duration(cfg.DurationMinutes) * time.Minute),
+		Trigger: trigger,
+		Joiners: burst,
+	}
+	r.active[guildID] = state
+	r.mu.Unlock()
+
+	var actions []string
+	var prevVerification *discordgo.VerificationLevel
+	var pausedInvites, lockdown bool
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		guild, err = s.Guild(guildID)
+	}
+	if err != nil {
+		fmt.Printf("Error obteniendo servidor %s para el modo raid: %v\n", guildID, err)
+	} else {
+		if cfg.RaiseVerification && guild.VerificationLevel < discordgo.VerificationLevelHigh {
+			level := discordgo.VerificationLevelHigh
+			if _, err := s.GuildEdit(guildID, &discordgo.GuildParams{VerificationLevel: &level}); err != nil {
+				fmt.Printf("Error subiendo la verificación en %s: %v\n", guildID, err)
+			} else {
+				prev := guild.VerificationLevel
+				prevVerification = &prev
+				actions = append(actions, "Verificación subida a alta")
+			}
+		}
+		if cfg.PauseInvites && !slices.Contains(guild.Features, discordgo.GuildFeatureInvitesDisabled) {
+			features := append(slices.Clone(guild.Features), discordgo.GuildFeatureInvitesDisabled)
+			if _, err := s.GuildEdit(guildID, &discordgo.GuildParams{Features: features}); err != nil {
+				fmt.Printf("Error pausando invitaciones en %s: %v\n", guildID, err)
+			} else {
+				pausedInvites = true
+				actions = append(actions, "Invitaciones pausadas")
+			}
+		}
+	}
+
+	// Si ya había un lockdown manual, el fin del raid no lo levanta
+	if cfg.Lockdown && !m.Lockdowns.IsActive(guildID) {
+		channels, err := LockdownChannels(s, guildID, LockdownAll, "")
+		if err != nil {
+			fmt.Printf("Error listando canales para el lockdown: %v\n", err)
+		} else if n := m.StartLockdown(s, guildID, channels, "Modo raid: "+trigger, "Sentinel"); n > 0 {
+			lockdown = true
+			actions = append(actions, fmt.Sprintf("%d canales bloqueados", n))
+		}
+	}
+
+	for _, userID := range burst {
+		m.actOnJoiner(s, guildID, userID, cfg)
+	}
+	switch cfg.Action {
+	case "timeout":
+		actions = append(actions, fmt.Sprintf("%d cuentas aisladas por %dh", len(burst), cfg.TimeoutHours))
+	case "kick":
+		actions = append(actions, fmt.Sprintf("%d cuentas expulsadas", len(burst)))
+	}
+
+	r.mu.Lock()
+	state.PrevVerification, state.PausedInvites, state.Lockdown = prevVerification, pausedInvites, lockdown
+	r.saveLocked()
+	r.mu.Unlock()
+	m.Scheduler.Schedule(Job{Type: JobRaidEnd, GuildID: guildID, At: state.Until, By: "Sentinel"})
+
+	if len(actions) == 0 {
+		actions = append(actions, "Ninguna")
+	}
+	ids := make(map[string]bool, len(burst))
+	for _, id := range burst {
+		ids[id] = true
+	}
+	mentions := joinMentions(ids, "<@%s>")
+	m.sendRaidReport(s, guildID, &discordgo.MessageEmbed{
+		Title: "🛡️ Modo Raid Activado",
+		Description: fmt.Sprintf("Motivo: **%s**\nAcciones: %s\nTermina: <t:%d:R>\nCuentas: %s",
+			trigger, strings.Join(actions, ", "), state.Until.Unix(), truncate(mentions, 1500)),
+		Color:     0xe74c3c,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+func (m *Manager) sendRaidReport(s *discordgo.Session, guildID string, embed *discordgo.MessageEmbed) {
+	channelID := m.GetEventsChannel(guildID)
+	if channelID == "" {
+		channelID = m.GetLogChannel(guildID)
+	}
+	if channelID == "" {
+		return
+	}
+	_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Terminar modo raid", Style: discordgo.DangerButton, CustomID: RaidEndButtonID},
+			}},
+		},
+	})
+	if err != nil {
+		fmt.Printf("Error enviando reporte de raid: %v\n", err)
+	}
+}
+
+// EndRaid deshace los cambios del modo raid. Devuelve false si no estaba
+// activo. Si algo no se pudo deshacer, el modo raid sigue activo solo con lo
+// pendiente y se devuelve el error para reintentarlo.
+func (m *Manager) EndRaid(s *discordgo.Session, guildID, by string) (bool, error) {
+	r := m.Raids
+	r.mu.Lock()
+	current, ok := r.active[guildID]
+	if !ok {
+		r.mu.Unlock()
+		return false, nil
+	}
+	state := *current
+	r.mu.Unlock()
+
+	var errs []error
+	if state.PrevVerification != nil {
+		if _, err := s.GuildEdit(guildID, &discordgo.GuildParams{VerificationLevel: state.PrevVerification}); err != nil {
+			errs = append(errs, fmt.Errorf("restaurando la verificación: %w", err))
+		} else {
+			state.PrevVerification = nil
+		}
+	}
+	if state.PausedInvites {
+		guild, err := s.Guild(guildID)
+		if err == nil {
+			features := slices.DeleteFunc(slices.Clone(guild.Features), func(f discordgo.GuildFeature) bool {
+				return f == discordgo.GuildFeatureInvitesDisabled
+			})
+			_, err = s.GuildEdit(guildID, &discordgo.GuildParams{Features: features})
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reactivando invitaciones: %w", err))
+		} else {
+			state.PausedInvites = false
+		}
+	}
+	if state.Lockdown {
+		m.EndLockdown(s, guildID, by)
+		if m.Lockdowns.IsActive(guildID) {
+			errs = append(errs, errors.New("quedan canales del lockdown sin restaurar"))
+		} else {
+			state.Lockdown = false
+		}
+	}
+
+	r.mu.Lock()
+	if err := errors.Join(errs...); err != nil {
+		if cur, ok := r.active[guildID]; ok {
+			cur.PrevVerification, cur.PausedInvites, cur.Lockdown = state.PrevVerification, state.PausedInvites, state.Lockdown
+			r.saveLocked()
+		}
+		r.mu.Unlock()
+		fmt.Printf("Error terminando el modo raid en %s: %v\n", guildID, err)
+		return true, err
+	}
+	if cur, ok := r.active[guildID]; ok {
+		state.Joiners = cur.Joiners
+	}
+	delete(r.active, guildID)
+	r.saveLocked()
+	r.mu.Unlock()
+	m.Scheduler.CancelFor(guildID, JobRaidEnd, "")
+
+	m.LogEvent(s, guildID, &discordgo.MessageEmbed{
+		Title:       "✅ Modo Raid Terminado",
+		Description: fmt.Sprintf("Por: %s\nDuración: %s\nCuentas que entraron durante el raid: %d", by, time.Since(state.Since).Round(time.Second), len(state.Joiners)),
+		Color:       0x2ecc71,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	})
+	return true, nil
+}
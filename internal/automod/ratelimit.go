@@ -0,0 +1,250 @@
+package automod
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// RateLimit define cuánto de cada métrica se permite por ventana. Un valor 0
+// desactiva esa métrica.
+type RateLimit struct {
+	Messages      int `json:"messages,omitempty"`
+	Mentions      int `json:"mentions,omitempty"`
+	Attachments   int `json:"attachments,omitempty"`
+	Links         int `json:"links,omitempty"`
+	Newlines      int `json:"newlines,omitempty"`
+	Characters    int `json:"characters,omitempty"`
+	WindowSeconds int `json:"window_seconds"`
+	MuteSeconds   int `json:"mute_seconds"`
+}
+
+type RateLimitConfig struct {
+	Default RateLimit `json:"default"`
+	// Límites para miembros que entraron hace menos de NewMemberHours
+	NewMembers     *RateLimit `json:"new_members,omitempty"`
+	NewMemberHours int        `json:"new_member_hours"`
+	// Límites propios por canal, con su propio contador
+	Channels map[string]RateLimit `json:"channels,omitempty"`
+}
+
+var DefaultRateLimitConfig = RateLimitConfig{
+	Default: RateLimit{
+		Messages:      5,
+		Mentions:      15,
+		Attachments:   10,
+		Links:         8,
+		Newlines:      60,
+		Characters:    6000,
+		WindowSeconds: 5,
+		MuteSeconds:   300,
+	},
+	NewMembers: &RateLimit{
+		Messages:      4,
+		Mentions:      5,
+		Attachments:   4,
+		Links:         3,
+		Newlines:      30,
+		Characters:    3000,
+		WindowSeconds: 5,
+		MuteSeconds:   600,
+	},
+	NewMemberHours: 24,
+}
+
+type rateMetric struct {
+	name  string
+	limit func(RateLimit) int
+	cost  func(*discordgo.Message) int
+}
+
+var rateMetrics = []rateMetric{
+	{"mensajes", func(l RateLimit) int { return l.Messages }, func(*discordgo.Message) int { return 1 }},
+	{"menciones", func(l RateLimit) int { return l.Mentions }, func(msg *discordgo.Message) int { return len(msg.Mentions) + len(msg.MentionRoles) }},
+	{"adjuntos", func(l RateLimit) int { return l.Attachments }, func(msg *discordgo.Message) int { return len(msg.Attachments) }},
+	{"links", func(l RateLimit) int { return l.Links }, func(msg *discordgo.Message) int {
+		lower := strings.ToLower(msg.Content)
+		return strings.Count(lower, "http://") + strings.Count(lower, "https://")
+	}},
+	{"saltos de línea", func(l RateLimit) int { return l.Newlines }, func(msg *discordgo.Message) int { return strings.Count(msg.Content, "\n") }},
+	{"caracteres", func(l RateLimit) int { return l.Characters }, func(msg *discordgo.Message) int { return len([]rune(msg.Content)) }},
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type burstMessage struct {
+	channelID string
+	messageID string
+	at        time.Time
+}
+
+type rateState struct {
+	buckets map[string]*tokenBucket
+	burst   []burstMessage
+	seen    time.Time
+}
+
+// RateLimiter lleva un token bucket por usuario, ámbito (servidor o canal) y
+// métrica, más los mensajes de la ráfaga para poder borrarlos juntos.
+type RateLimiter struct {
+	mu        sync.Mutex
+	states    map[string]*rateState
+	lastPrune time.Time
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{states: make(map[string]*rateState)}
+}
+
+// Consume descuenta el costo del mensaje de cada bucket. Si alguna métrica
+// se queda sin tokens devuelve su nombre y la ráfaga, y reinicia el estado.
+func (r *RateLimiter) Consume(key string, limit RateLimit, msg *discordgo.Message) (string, []burstMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	window := time.Duration(max(limit.WindowSeconds, 1)) * time.Second
+	r.pruneLocked(now)
+
+	st, ok := r.states[key]
+	if !ok {
+		st = &rateState{buckets: make(map[string]*tokenBucket)}
+		r.states[key] = st
+	}
+	st.seen = now
+
+	var kept []burstMessage
+	for _, b := range st.burst {
+		if now.Sub(b.at) < window {
+			kept = append(kept, b)
+		}
+	}
+	st.burst = append(kept, burstMessage{channelID: msg.ChannelID, messageID: msg.ID, at: now})
+
+	exceeded := ""
+	for _, metric := range rateMetrics {
+		capacity := float64(metric.limit(limit))
+		cost := float64(metric.cost(msg))
+		if capacity <= 0 || cost == 0 {
+			continue
+		}
+
+		b, ok := st.buckets[metric.name]
+		if !ok {
+			b = &tokenBucket{tokens: capacity, last: now}
+			st.buckets[metric.name] = b
+		}
+		b.tokens = min(capacity, b.tokens+now.Sub(b.last).Seconds()*capacity/window.Seconds())
+		b.last = now
+		b.tokens -= cost
+
+		if b.tokens < 0 && exceeded == "" {
+			exceeded = fmt.Sprintf("%s (más de %d en %s)", metric.name, int(capacity), window)
+		}
+	}
+
+	if exceeded == "" {
+		return "", nil
+	}
+
+	burst := st.burst
+	delete(r.states, key)
+	return exceeded, burst
+}
+
+func (r *RateLimiter) pruneLocked(now time.Time) {
+	if now.Sub(r.lastPrune) < time.Minute {
+		return
+	}
+	r.lastPrune = now
+	for key, st := range r.states {
+		if now.Sub(st.seen) > 10*time.Minute {
+			delete(r.states, key)
+		}
+	}
+}
+
+func (m *Manager) rateLimitConfig(guildID string) RateLimitConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok && cfg.RateLimits != nil {
+		return *cfg.RateLimits
+	}
+	return DefaultRateLimitConfig
+}
+
+// stricter combina dos límites tomando el menor valor activo de cada métrica,
+// la ventana más larga y el mute más largo.
+func stricter(a, b RateLimit) RateLimit {
+	pick := func(x, y int) int {
+		if x <= 0 || (y > 0 && y < x) {
+			return y
+		}
+		return x
+	}
+	return RateLimit{
+		Messages:      pick(a.Messages, b.Messages),
+		Mentions:      pick(a.Mentions, b.Mentions),
+		Attachments:   pick(a.Attachments, b.Attachments),
+		Links:         pick(a.Links, b.Links),
+		Newlines:      pick(a.Newlines, b.Newlines),
+		Characters:    pick(a.Characters, b.Characters),
+		WindowSeconds: max(a.WindowSeconds, b.WindowSeconds),
+		MuteSeconds:   max(a.MuteSeconds, b.MuteSeconds),
+	}
+}
+
+// checkRateLimit descuenta el mensaje del contador del servidor y, si el
+// canal tiene límite propio, también del contador del canal. A los miembros
+// nuevos se les aplica además el límite reducido. Si se supera alguno, borra
+// toda la ráfaga salvo el mensaje actual, que borra TakeAction.
+func (m *Manager) checkRateLimit(s *discordgo.Session, msg *discordgo.MessageCreate) *Detection {
+	cfg := m.rateLimitConfig(msg.GuildID)
+	isNew := msg.Member != nil && time.Since(msg.Member.JoinedAt) < time.Duration(cfg.NewMemberHours)*time.Hour
+	limitFor := func(base RateLimit) RateLimit {
+		if isNew && cfg.NewMembers != nil {
+			return stricter(base, *cfg.NewMembers)
+		}
+		return base
+	}
+
+	prefix := msg.GuildID + ":" + msg.Author.ID + ":"
+	limit := limitFor(cfg.Default)
+	exceeded, burst := m.RateLimiter.Consume(prefix+"guild", limit, msg.Message)
+	if chLimit, ok := cfg.Channels[msg.ChannelID]; ok {
+		chLimit = limitFor(chLimit)
+		if chExceeded, chBurst := m.RateLimiter.Consume(prefix+msg.ChannelID, chLimit, msg.Message); chExceeded != "" {
+			if exceeded == "" {
+				exceeded, limit = chExceeded, chLimit
+			}
+			burst = append(burst, chBurst...)
+		}
+	}
+	if exceeded == "" {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	byChannel := make(map[string][]string)
+	for _, b := range burst {
+		if b.messageID != msg.ID && !seen[b.messageID] {
+			seen[b.messageID] = true
+			byChannel[b.channelID] = append(byChannel[b.channelID], b.messageID)
+		}
+	}
+	for channelID, ids := range byChannel {
+		m.deleteMessages(s, channelID, ids)
+	}
+
+	detail := fmt.Sprintf("Límite superado: %s\nMensajes de la ráfaga borrados: %d", exceeded, len(seen)+1)
+	if isNew {
+		detail += "\nMiembro nuevo (límites reducidos)"
+	}
+	return &Detection{Reason: "Spam", Detail: detail, Mute: time.Duration(limit.MuteSeconds) * time.Second}
+}
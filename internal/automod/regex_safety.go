@@ -0,0 +1,200 @@
+package automod
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dlclark/regexp2"
+)
+
+const (
+	// FilterMatchTimeout es el tiempo máximo de una sola búsqueda de regex.
+	FilterMatchTimeout = 100 * time.Millisecond
+	// FilterBudget es el tiempo total que pueden consumir los filtros por mensaje.
+	FilterBudget = 300 * time.Millisecond
+	// validationTimeout es el límite por entrada adversaria al validar patrones.
+	validationTimeout = 50 * time.Millisecond
+)
+
+// Detecta cuantificadores anidados tipo (a+)+, (\w*)* o (x+){2,}, la causa
+// típica de backtracking catastrófico.
+var nestedQuantifier = regexp2.MustCompile(`\((?:[^()\\]|\\.)*[+*](?:[^()\\]|\\.)*\)(?:[+*]|\{\d+,\d*\})`, 0)
+
+type CustomFilter struct {
+	Pattern     string `json:"pattern"`
+	Mute        bool   `json:"mute"`
+	WarnMessage string `json:"warn_message,omitempty"`
+}
+
+// FilterStats guarda métricas de timeouts de los filtros, por patrón.
+type FilterStats struct {
+	mu       sync.Mutex
+	timeouts map[string]int
+}
+
+func (f *FilterStats) recordTimeout(pattern string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.timeouts == nil {
+		f.timeouts = make(map[string]int)
+	}
+	f.timeouts[pattern]++
+	return f.timeouts[pattern]
+}
+
+// Timeouts devuelve una copia de los timeouts acumulados por patrón.
+func (f *FilterStats) Timeouts() map[string]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make(map[string]int, len(f.timeouts))
+	for k, v := range f.timeouts {
+		out[k] = v
+	}
+	return out
+}
+
+// compileFilter compila un patrón de confianza (definido en código) con timeout.
+func compileFilter(pattern string, opts regexp2.RegexOptions) *regexp2.Regexp {
+	re := regexp2.MustCompile(pattern, opts)
+	re.MatchTimeout = FilterMatchTimeout
+	return re
+}
+
+// ValidatePattern compila un patrón externo (usuario o archivo), rechaza
+// cuantificadores anidados y lo ejecuta contra entradas adversarias.
+func ValidatePattern(pattern string, opts regexp2.RegexOptions) (*regexp2.Regexp, error) {
+	re, err := regexp2.Compile(pattern, opts)
+	if err != nil {
+		return nil, fmt.Errorf("patrón inválido: %w", err)
+	}
+
+	if match, _ := nestedQuantifier.FindStringMatch(pattern); match != nil {
+		return nil, fmt.Errorf("cuantificador anidado peligroso: `%s`", match.String())
+	}
+
+	re.MatchTimeout = validationTimeout
+	for _, input := range adversarialInputs(pattern) {
+		start := time.Now()
+		if _, err := re.FindStringMatch(input); err != nil {
+			return nil, fmt.Errorf("el patrón excede %s con una entrada de %d caracteres", validationTimeout, len(input))
+		}
+		if elapsed := time.Since(start); elapsed > validationTimeout/2 {
+			return nil, fmt.Errorf("el patrón es demasiado lento (%s con %d caracteres)", elapsed, len(input))
+		}
+	}
+
+	re.MatchTimeout = FilterMatchTimeout
+	return re, nil
+}
+
+// adversarialInputs arma textos largos y repetitivos, incluyendo los literales
+// del propio patrón, terminados en un carácter que fuerza el fallo del match.
+func adversarialInputs(pattern string) []string {
+	const n = 4000
+	seeds := []string{"a", " ", "1", "a ", "a.", "-_", "http://a."}
+
+	var literal strings.Builder
+	for _, r := range pattern {
+		if strings.ContainsRune(`\()[]{}?*+|^$.`, r) {
+			continue
+		}
+		literal.WriteRune(r)
+	}
+	if literal.Len() > 0 {
+		seeds = append(seeds, literal.String())
+	}
+
+	var inputs []string
+	for _, seed := range seeds {
+		body := strings.Repeat(seed, n/len(seed)+1)
+		inputs = append(inputs, body+"!", body+"\n\x00")
+	}
+	return inputs
+}
+
+// matchFilter ejecuta una regex registrando los timeouts en vez de
+// tratarlos como "sin match".
+func (m *Manager) matchFilter(re *regexp2.Regexp, text string) *regexp2.Match {
+	match, err := re.FindStringMatch(text)
+	if err != nil {
+		count := m.FilterStats.recordTimeout(re.String())
+		fmt.Printf("Timeout de filtro (%d en total) con %d caracteres: %s\n", count, len(text), re.String())
+		return nil
+	}
+	return match
+}
+
+// LoadCustomFilters compila los filtros propios de cada servidor y los del
+// archivo global, que ya no se escribe desde el bot pero se sigue leyendo
+// para no perder los filtros agregados antes de que fueran por servidor.
+func (m *Manager) LoadCustomFilters() {
+	var global []IFilter
+	data, err := os.ReadFile(m.filtersPath)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Error leyendo filtros en %s: %v\n", m.filtersPath, err)
+	}
+	if err == nil {
+		var custom []CustomFilter
+		if err := json.Unmarshal(data, &custom); err != nil {
+			fmt.Printf("Error deserializando filtros: %v\n", err)
+		}
+		global = compileCustomFilters(custom)
+	}
+
+	m.mu.RLock()
+	perGuild := make(map[string][]CustomFilter)
+	for guildID, cfg := range m.GuildConfig {
+		if len(cfg.CustomFilters) > 0 {
+			perGuild[guildID] = slices.Clone(cfg.CustomFilters)
+		}
+	}
+	m.mu.RUnlock()
+
+	// La validación corre cada patrón contra entradas adversarias: se hace sin el lock
+	compiled := make(map[string][]IFilter, len(perGuild))
+	for guildID, custom := range perGuild {
+		compiled[guildID] = compileCustomFilters(custom)
+	}
+
+	m.mu.Lock()
+	m.SpamFilters = slices.Concat(m.SpamFilters, global)
+	m.guildFilters = compiled
+	m.mu.Unlock()
+}
+
+func compileCustomFilters(custom []CustomFilter) []IFilter {
+	var filters []IFilter
+	for _, cf := range custom {
+		re, err := ValidatePattern(cf.Pattern, regexp2.IgnoreCase)
+		if err != nil {
+			fmt.Printf("Filtro ignorado `%s`: %v\n", cf.Pattern, err)
+			continue
+		}
+		filters = append(filters, IFilter{Filter: re, Mute: cf.Mute, WarnMessage: cf.WarnMessage})
+	}
+	return filters
+}
+
+// AddCustomFilter valida y agrega un filtro nuevo solo para el servidor.
+func (m *Manager) AddCustomFilter(guildID, pattern string, mute bool, warnMessage string) error {
+	re, err := ValidatePattern(pattern, regexp2.IgnoreCase)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	m.GuildConfig[guildID].CustomFilters = append(m.GuildConfig[guildID].CustomFilters, CustomFilter{Pattern: pattern, Mute: mute, WarnMessage: warnMessage})
+	// Copia nueva: detectText puede estar recorriendo la anterior
+	m.guildFilters[guildID] = append(slices.Clip(m.guildFilters[guildID]), IFilter{Filter: re, Mute: mute, WarnMessage: warnMessage})
+	m.mu.Unlock()
+	m.SaveConfig()
+	return nil
+}
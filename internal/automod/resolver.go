@@ -0,0 +1,244 @@
+package automod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	resolverMaxHops  = 5
+	resolverTimeout  = 5 * time.Second
+	resolverCacheTTL = time.Hour
+	// Los errores (timeouts, caídas) se reintentan pronto
+	resolverErrorTTL = time.Minute
+)
+
+// LinkResolver sigue redirecciones (acortadores, redirects abiertos) con un
+// límite de saltos, pidiendo HEAD primero y GET solo si el servidor no lo acepta.
+type LinkResolver struct {
+	// Client no debe seguir redirecciones por su cuenta; ver NewLinkResolver.
+	Client  *http.Client
+	MaxHops int
+
+	mu    sync.Mutex
+	cache map[string]resolvedChain
+}
+
+type resolvedChain struct {
+	hops    []string
+	err     error
+	expires time.Time
+}
+
+// NewLinkResolver crea un resolver con un cliente que no sigue redirecciones
+// y se niega a conectarse a direcciones privadas o locales.
+func NewLinkResolver() *LinkResolver {
+	dialer := &net.Dialer{
+		Timeout: resolverTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+				return fmt.Errorf("dirección no permitida: %s", host)
+			}
+			return nil
+		},
+	}
+
+	return NewLinkResolverWithClient(&http.Client{
+		Timeout:   resolverTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	})
+}
+
+// NewLinkResolverWithClient usa el cliente dado (por ejemplo, uno que apunte a
+// un servidor local de pruebas), desactivando su seguimiento de redirecciones.
+func NewLinkResolverWithClient(client *http.Client) *LinkResolver {
+	c := *client
+	c.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &LinkResolver{
+		Client:  &c,
+		MaxHops: resolverMaxHops,
+		cache:   make(map[string]resolvedChain),
+	}
+}
+
+// Resolve devuelve la cadena de URLs, empezando por la original. Si se corta
+// por error o por límite de saltos, devuelve lo recorrido junto al error.
+func (r *LinkResolver) Resolve(ctx context.Context, raw string) ([]string, error) {
+	r.mu.Lock()
+	if cached, ok := r.cache[raw]; ok && time.Now().Before(cached.expires) {
+		r.mu.Unlock()
+		return cached.hops, cached.err
+	}
+	r.mu.Unlock()
+
+	hops, err := r.follow(ctx, raw)
+
+	// Si se acabó el tiempo del llamador el error no dice nada del link
+	if ctx.Err() != nil {
+		return hops, err
+	}
+	ttl := resolverCacheTTL
+	if err != nil {
+		ttl = resolverErrorTTL
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	for key, cached := range r.cache {
+		if now.After(cached.expires) {
+			delete(r.cache, key)
+		}
+	}
+	r.cache[raw] = resolvedChain{hops: hops, err: err, expires: now.Add(ttl)}
+	r.mu.Unlock()
+
+	return hops, err
+}
+
+func (r *LinkResolver) follow(ctx context.Context, raw string) ([]string, error) {
+	hops := []string{raw}
+	current := raw
+
+	for i := 0; i < r.MaxHops; i++ {
+		next, err := r.step(ctx, current)
+		if err != nil {
+			return hops, err
+		}
+		if next == "" {
+			return hops, nil
+		}
+		hops = append(hops, next)
+		current = next
+	}
+	return hops, fmt.Errorf("demasiadas redirecciones (más de %d)", r.MaxHops)
+}
+
+// step hace una petición y devuelve el destino de la redirección, o "" si no la hay.
+func (r *LinkResolver) step(ctx context.Context, current string) (string, error) {
+	resp, err := r.request(ctx, http.MethodHead, current)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = r.request(ctx, http.MethodGet, current)
+	}
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return "", nil
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", nil
+	}
+
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	next, err := base.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	if next.Scheme != "http" && next.Scheme != "https" {
+		return "", errors.New("redirección a un esquema no soportado: " + next.Scheme)
+	}
+	return next.String(), nil
+}
+
+func (r *LinkResolver) request(ctx context.Context, method, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; SentinelBot/1.0)")
+	return r.Client.Do(req)
+}
+
+func formatChain(hops []string) string {
+	lines := make([]string, len(hops))
+	for i, hop := range hops {
+		lines[i] = fmt.Sprintf("%d. `%s`", i+1, truncate(hop, 150))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *Manager) SetResolveRedirects(guildID string, enabled bool) {
+	m.mu.Lock()
+	if _, ok := m.GuildConfig[guildID]; !ok {
+		m.GuildConfig[guildID] = &Config{}
+	}
+	m.GuildConfig[guildID].ResolveRedirects = enabled
+	m.mu.Unlock()
+	m.SaveConfig()
+}
+
+func (m *Manager) IsResolveRedirectsEnabled(guildID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if cfg, ok := m.GuildConfig[guildID]; ok {
+		return cfg.ResolveRedirects
+	}
+	return false
+}
+
+// checkRedirects sigue en segundo plano los links del mensaje y pasa cada
+// salto por la reputación de dominios. El primer salto ya lo revisó checkLinks.
+func (m *Manager) checkRedirects(s *discordgo.Session, msg *discordgo.MessageCreate) {
+	if m.Domains == nil || !m.IsResolveRedirectsEnabled(msg.GuildID) {
+		return
+	}
+
+	links := ExtractLinks(msg.Content, msg.Embeds)
+	if len(links) == 0 {
+		return
+	}
+	if len(links) > 5 {
+		links = links[:5]
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*resolverTimeout)
+		defer cancel()
+
+		for _, link := range links {
+			hops, err := m.Resolver.Resolve(ctx, link.URL)
+			for i, hop := range hops[1:] {
+				v := m.Domains.Check(LinkHost(hop))
+				if !v.Blocked {
+					continue
+				}
+				detail := fmt.Sprintf("%s\nEl enlace redirige a un dominio sancionable en el salto %d.\n%s\nCadena:\n%s",
+					LINK_SOSPECHOSO, i+1, v.Reason, formatChain(hops))
+				reason := "Dominio Sospechoso"
+				if v.ListMatch != "" {
+					reason = "Dominio Bloqueado"
+				}
+				m.TakeAction(s, msg, reason, detail, 7*24*time.Hour, nil)
+				return
+			}
+			if err != nil {
+				fmt.Printf("No se pudo resolver %s: %v\n", link.URL, err)
+			}
+		}
+	}()
+}
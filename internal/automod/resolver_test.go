@@ -0,0 +1,66 @@
+package automod
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// redirectServer redirige /hop/N a /hop/N-1 hasta llegar a /hop/0.
+func redirectServer(t *testing.T, head bool) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodHead && !head {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var n int
+		fmt.Sscanf(strings.TrimPrefix(req.URL.Path, "/hop/"), "%d", &n)
+		if n > 0 {
+			http.Redirect(w, req, fmt.Sprintf("/hop/%d", n-1), http.StatusFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestResolveChain(t *testing.T) {
+	for _, head := range []bool{true, false} {
+		srv := redirectServer(t, head)
+		r := NewLinkResolverWithClient(srv.Client())
+		hops, err := r.Resolve(context.Background(), srv.URL+"/hop/2")
+		if err != nil {
+			t.Fatalf("head=%v: error inesperado: %v", head, err)
+		}
+		want := []string{srv.URL + "/hop/2", srv.URL + "/hop/1", srv.URL + "/hop/0"}
+		if !slices.Equal(hops, want) {
+			t.Errorf("head=%v: cadena %v, se esperaba %v", head, hops, want)
+		}
+	}
+}
+
+func TestResolveHopLimit(t *testing.T) {
+	srv := redirectServer(t, true)
+	r := NewLinkResolverWithClient(srv.Client())
+	hops, err := r.Resolve(context.Background(), srv.URL+"/hop/10")
+	if err == nil {
+		t.Fatal("se esperaba error por demasiadas redirecciones")
+	}
+	if len(hops) != resolverMaxHops+1 {
+		t.Errorf("%d saltos recorridos, se esperaban %d", len(hops), resolverMaxHops+1)
+	}
+}
+
+func TestResolveRejectsPrivateAddress(t *testing.T) {
+	srv := redirectServer(t, true)
+	hops, err := NewLinkResolver().Resolve(context.Background(), srv.URL+"/hop/1")
+	if err == nil || !strings.Contains(err.Error(), "dirección no permitida") {
+		t.Fatalf("se esperaba rechazo de la dirección local, error: %v", err)
+	}
+	if len(hops) != 1 {
+		t.Errorf("cadena %v, no debería seguir ningún salto", hops)
+	}
+}
@@ -0,0 +1,303 @@
+package automod
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Tipos de tareas programadas
+const (
+	JobUnban       = "unban"
+	JobRelease     = "release"
+	JobLockdownEnd = "lockdown_end"
+	JobRaidEnd     = "raid_end"
+	JobReminder    = "reminder"
+)
+
+// Una tarea que falla (error de la API, caída de Discord) se reintenta
+// duplicando la espera hasta jobRetryMax, para no perderla.
+const (
+	jobRetryBase = time.Minute
+	jobRetryMax  = time.Hour
+)
+
+type Job struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	GuildID   string    `json:"guild_id"`
+	UserID    string    `json:"user_id,omitempty"`
+	ChannelID string    `json:"channel_id,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	At        time.Time `json:"at"`
+	By        string    `json:"by"`
+	Attempts  int       `json:"attempts,omitempty"`
+}
+
+type schedulerFile struct {
+	NextID int    `json:"next_id"`
+	Jobs   []*Job `json:"jobs"`
+}
+
+// Scheduler guarda en disco las tareas con hora y las ejecuta aunque el bot
+// se haya reiniciado; las que vencieron mientras estaba apagado corren al arrancar.
+type Scheduler struct {
+	mu     sync.Mutex
+	path   string
+	nextID int
+	jobs   map[string]*Job
+	timers map[string]*time.Timer
+	run    func(Job) error
+}
+
+func NewScheduler(path string) *Scheduler {
+	sc := &Scheduler{
+		path:   path,
+		nextID: 1,
+		jobs:   make(map[string]*Job),
+		timers: make(map[string]*time.Timer),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error leyendo tareas en %s: %v\n", path, err)
+		}
+		return sc
+	}
+	var file schedulerFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		fmt.Printf("Error deserializando tareas: %v\n", err)
+		return sc
+	}
+	sc.nextID = max(file.NextID, 1)
+	for _, job := range file.Jobs {
+		sc.jobs[job.ID] = job
+	}
+	return sc
+}
+
+// saveLocked debe llamarse con sc.mu tomado.
+func (sc *Scheduler) saveLocked() {
+	file := schedulerFile{NextID: sc.nextID}
+	for _, job := range sc.jobs {
+		file.Jobs = append(file.Jobs, job)
+	}
+	slices.SortFunc(file.Jobs, func(a, b *Job) int { return a.At.Compare(b.At) })
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		fmt.Printf("Error serializando tareas: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(sc.path, data, 0644); err != nil {
+		fmt.Printf("Error guardando tareas en %s: %v\n", sc.path, err)
+	}
+}
+
+// armLocked programa el timer de la tarea; debe llamarse con sc.mu tomado.
+func (sc *Scheduler) armLocked(job *Job) {
+	if sc.run == nil {
+		return
+	}
+	if t, ok := sc.timers[job.ID]; ok {
+		t.Stop()
+	}
+	id := job.ID
+	sc.timers[id] = time.AfterFunc(time.Until(job.At), func() { sc.fire(id) })
+}
+
+func (sc *Scheduler) fire(id string) {
+	sc.mu.Lock()
+	job, ok := sc.jobs[id]
+	if !ok {
+		sc.mu.Unlock()
+		return
+	}
+	copied := *job
+	run := sc.run
+	sc.mu.Unlock()
+
+	err := run(copied)
+
+	// Se borra después de correr para no perderla si el bot se cae a mitad
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	// Pudo cancelarse o reemplazarse mientras corría
+	if sc.jobs[id] != job {
+		return
+	}
+	if err != nil {
+		job.Attempts++
+		job.At = time.Now().Add(min(jobRetryBase<<min(job.Attempts-1, 10), jobRetryMax))
+		fmt.Printf("Error en la tarea %s (%s), se reintenta a las %s: %v\n", id, job.Type, job.At.Format(time.TimeOnly), err)
+		sc.armLocked(job)
+		sc.saveLocked()
+		return
+	}
+	delete(sc.jobs, id)
+	delete(sc.timers, id)
+	sc.saveLocked()
+}
+
+// Start programa todas las tareas guardadas con la función que las ejecuta.
+func (sc *Scheduler) Start(run func(Job) error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.run = run
+	for _, job := range sc.jobs {
+		sc.armLocked(job)
+	}
+}
+
+// Schedule guarda y programa la tarea. Salvo los recordatorios, reemplaza a
+// la pendiente del mismo tipo para el mismo servidor y usuario.
+func (sc *Scheduler) Schedule(job Job) string {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if job.Type != JobReminder {
+		for id, old := range sc.jobs {
+			if old.Type == job.Type && old.GuildID == job.GuildID && old.UserID == job.UserID {
+				sc.cancelLocked(id)
+			}
+		}
+	}
+
+	job.ID = strconv.Itoa(sc.nextID)
+	sc.nextID++
+	sc.jobs[job.ID] = &job
+	sc.armLocked(&job)
+	sc.saveLocked()
+	return job.ID
+}
+
+// cancelLocked debe llamarse con sc.mu tomado.
+func (sc *Scheduler) cancelLocked(id string) {
+	if t, ok := sc.timers[id]; ok {
+		t.Stop()
+		delete(sc.timers, id)
+	}
+	delete(sc.jobs, id)
+}
+
+// Cancel borra la tarea si pertenece al servidor.
+func (sc *Scheduler) Cancel(guildID, id string) (Job, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	job, ok := sc.jobs[id]
+	if !ok || job.GuildID != guildID {
+		return Job{}, false
+	}
+	sc.cancelLocked(id)
+	sc.saveLocked()
+	return *job, true
+}
+
+// CancelFor borra las tareas pendientes de ese tipo para el servidor y usuario.
+func (sc *Scheduler) CancelFor(guildID, jobType, userID string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	changed := false
+	for id, job := range sc.jobs {
+		if job.Type == jobType && job.GuildID == guildID && job.UserID == userID {
+			sc.cancelLocked(id)
+			changed = true
+		}
+	}
+	if changed {
+		sc.saveLocked()
+	}
+}
+
+// List devuelve las tareas del servidor ordenadas por hora.
+func (sc *Scheduler) List(guildID string) []Job {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	var jobs []Job
+	for _, job := range sc.jobs {
+		if job.GuildID == guildID {
+			jobs = append(jobs, *job)
+		}
+	}
+	slices.SortFunc(jobs, func(a, b Job) int { return a.At.Compare(b.At) })
+	return jobs
+}
+
+// DescribeJob es la línea con la que se muestra una tarea en /jobs list.
+func DescribeJob(job Job) string {
+	var what string
+	switch job.Type {
+	case JobUnban:
+		what = fmt.Sprintf("Desbanear a <@%s>", job.UserID)
+	case JobRelease:
+		what = fmt.Sprintf("Sacar de la cuarentena a <@%s>", job.UserID)
+	case JobLockdownEnd:
+		what = "Terminar el lockdown"
+	case JobRaidEnd:
+		what = "Terminar el modo raid"
+	case JobReminder:
+		what = "Recordatorio: " + truncate(job.Note, 100)
+	default:
+		what = job.Type
+	}
+	line := fmt.Sprintf("`%s` <t:%d:R> %s (por %s)", job.ID, job.At.Unix(), what, job.By)
+	if job.Attempts > 0 {
+		line += fmt.Sprintf(" ⚠️ intentos fallidos: %d", job.Attempts)
+	}
+	return line
+}
+
+// StartScheduler arranca el scheduler; debe llamarse con la sesión ya abierta.
+func (m *Manager) StartScheduler(s *discordgo.Session) {
+	m.Scheduler.Start(func(job Job) error { return m.runJob(s, job) })
+}
+
+// runJob ejecuta la tarea; si devuelve error el scheduler la reintenta.
+func (m *Manager) runJob(s *discordgo.Session, job Job) error {
+	switch job.Type {
+	case JobUnban:
+		if err := s.GuildBanDelete(job.GuildID, job.UserID, discordgo.WithAuditLogReason("Fin del ban temporal")); err != nil && !isNotFound(err) {
+			return fmt.Errorf("desbaneando a %s: %w", job.UserID, err)
+		}
+		m.LogEvent(s, job.GuildID, &discordgo.MessageEmbed{
+			Title:       "⏱️ Ban Temporal Terminado",
+			Description: fmt.Sprintf("Usuario: <@%s>\nBaneado por: %s", job.UserID, job.By),
+			Color:       0x2ecc71,
+			Timestamp:   time.Now().Format(time.RFC3339),
+		})
+	case JobRelease:
+		if _, err := m.ReleaseMember(s, job.GuildID, job.UserID, "fin automático"); err != nil && err != errNotQuarantined {
+			return fmt.Errorf("liberando a %s de la cuarentena: %w", job.UserID, err)
+		}
+	case JobLockdownEnd:
+		m.EndLockdown(s, job.GuildID, "fin automático")
+		if m.Lockdowns.IsActive(job.GuildID) {
+			return errors.New("quedan canales del lockdown sin restaurar")
+		}
+	case JobRaidEnd:
+		if _, err := m.EndRaid(s, job.GuildID, "fin automático"); err != nil {
+			return err
+		}
+	case JobReminder:
+		channelID := job.ChannelID
+		if channelID == "" {
+			channelID = m.GetEventsChannel(job.GuildID)
+		}
+		if channelID == "" {
+			return nil
+		}
+		// Un canal borrado no va a volver
+		if _, err := s.ChannelMessageSend(channelID, fmt.Sprintf("⏰ %s, recordatorio: %s", job.By, job.Note)); err != nil && !isNotFound(err) {
+			return fmt.Errorf("enviando recordatorio: %w", err)
+		}
+	default:
+		fmt.Printf("Tarea %s de tipo desconocido: %s\n", job.ID, job.Type)
+	}
+	return nil
+}
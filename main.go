@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
@@ -52,11 +53,124 @@ func main() {
 	if err != nil {
 		log.Printf("Advertencia: Error cargando imágenes de scam (%v)", err)
 	}
+	avatarPath := "./assets/avatars"
+	manager.LoadAvatarLibrary(scamPath, avatarPath)
+	manager.LoadDomainLists("./assets/domains")
+	manager.LoadHashList("./assets/attachments/sha256.txt")
+	manager.LoadOCR()
 
 	dg.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
 		manager.AnalyzeMessage(s, m)
 	})
 
+	dg.AddHandler(func(s *discordgo.Session, m *discordgo.MessageUpdate) {
+		manager.AnalyzeMessageUpdate(s, m)
+	})
+
+	dg.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+		manager.HandleMemberJoin(s, m)
+	})
+
+	dg.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+		manager.HandleMemberUpdate(s, m)
+	})
+
+	dg.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+		manager.HandleMemberLeave(s, m)
+	})
+
+	dg.AddHandler(func(s *discordgo.Session, g *discordgo.GuildCreate) {
+		manager.HandleGuildCreate(s, g)
+	})
+
+	dg.AddHandler(func(s *discordgo.Session, c *discordgo.GuildMembersChunk) {
+		manager.HandleMembersChunk(s, c)
+	})
+
+	dg.AddHandler(func(s *discordgo.Session, b *discordgo.GuildBanAdd) {
+		manager.HandleBanAdd(s, b)
+	})
+
+	dg.AddHandler(func(s *discordgo.Session, b *discordgo.GuildBanRemove) {
+		manager.HandleBanRemove(s, b)
+	})
+
+	dg.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		if i.Type != discordgo.InteractionMessageComponent {
+			return
+		}
+
+		customID := i.MessageComponentData().CustomID
+		switch {
+		case customID == automod.RaidEndButtonID:
+			if i.Member.Permissions&discordgo.PermissionManageGuild == 0 {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "Necesitas permiso de gestionar el servidor para terminar el modo raid.",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+			content := fmt.Sprintf("Modo raid terminado por <@%s>.", i.Member.User.ID)
+			ended, err := manager.EndRaid(s, i.GuildID, fmt.Sprintf("<@%s>", i.Member.User.ID))
+			switch {
+			case !ended:
+				content = "El modo raid ya había terminado."
+			case err != nil:
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: fmt.Sprintf("No se pudo deshacer todo el modo raid (%v). Vuelve a intentarlo.", err),
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseUpdateMessage,
+				Data: &discordgo.InteractionResponseData{
+					Content:    content,
+					Embeds:     i.Message.Embeds,
+					Components: []discordgo.MessageComponent{},
+				},
+			})
+
+		case strings.HasPrefix(customID, automod.BanEvasionButtonPrefix):
+			if i.Member.Permissions&discordgo.PermissionBanMembers == 0 {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "Necesitas permiso de baneo para usar este botón.",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+			userID := strings.TrimPrefix(customID, automod.BanEvasionButtonPrefix)
+			reason := fmt.Sprintf("Evasión de ban, confirmado por %s", i.Member.User.Username)
+			if err := s.GuildBanCreateWithReason(i.GuildID, userID, reason, 1); err != nil {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: fmt.Sprintf("No se pudo banear: %v", err),
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseUpdateMessage,
+				Data: &discordgo.InteractionResponseData{
+					Content:    fmt.Sprintf("<@%s> baneado por <@%s>.", userID, i.Member.User.ID),
+					Embeds:     i.Message.Embeds,
+					Components: []discordgo.MessageComponent{},
+				},
+			})
+		}
+	})
+
 	dg.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		if i.Type != discordgo.InteractionApplicationCommand {
 			return
@@ -111,67 +225,719 @@ func main() {
 							Flags:   discordgo.MessageFlagsEphemeral,
 						},
 					})
+				case "resolve-links":
+					enabled := opt.BoolValue()
+					manager.SetResolveRedirects(i.GuildID, enabled)
+					status := "desactivado"
+					if enabled {
+						status = "activado"
+					}
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: fmt.Sprintf("Seguimiento de redirecciones %s correctamente.", status),
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "attachment-filter":
+					enabled := opt.BoolValue()
+					manager.SetAttachmentFilter(i.GuildID, enabled)
+					status := "desactivado"
+					if enabled {
+						status = "activado"
+					}
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: fmt.Sprintf("Filtro de adjuntos peligrosos %s correctamente.", status),
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "ocr":
+					enabled := opt.BoolValue()
+					if enabled && manager.OCR == nil {
+						s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+							Type: discordgo.InteractionResponseChannelMessageWithSource,
+							Data: &discordgo.InteractionResponseData{
+								Content: "Los modelos de OCR no están instalados en el bot.",
+								Flags:   discordgo.MessageFlagsEphemeral,
+							},
+						})
+						continue
+					}
+					manager.SetOCR(i.GuildID, enabled)
+					status := "desactivado"
+					if enabled {
+						status = "activado"
+					}
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: fmt.Sprintf("OCR de imágenes %s correctamente.", status),
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "raid-protection":
+					enabled := opt.BoolValue()
+					manager.SetRaidProtection(i.GuildID, enabled)
+					status := "desactivada"
+					if enabled {
+						status = "activada"
+					}
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: fmt.Sprintf("Protección contra raids %s correctamente.", status),
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "quarantine-mode":
+					enabled := opt.BoolValue()
+					manager.SetQuarantineMode(i.GuildID, enabled)
+					status := "desactivado"
+					if enabled {
+						status = "activado"
+					}
+					content := fmt.Sprintf("Modo cuarentena %s correctamente.", status)
+					if enabled && manager.GetQuarantineRole(i.GuildID) == "" {
+						content += " Falta configurar `quarantine-role`; hasta entonces se sigue usando el aislamiento."
+					}
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: content,
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "quarantine-channel":
+					channel := opt.ChannelValue(s)
+					manager.SetQuarantineChannel(i.GuildID, channel.ID)
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: fmt.Sprintf("Canal de revisión de cuarentena configurado en <#%s>.", channel.ID),
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "compromised-detection":
+					enabled := opt.BoolValue()
+					manager.SetCompromisedDetection(i.GuildID, enabled)
+					status := "desactivada"
+					if enabled {
+						status = "activada"
+					}
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: fmt.Sprintf("Detección de cuentas comprometidas %s correctamente.", status),
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "ban-evasion":
+					enabled := opt.BoolValue()
+					manager.SetBanEvasion(i.GuildID, enabled)
+					status := "desactivada"
+					if enabled {
+						status = "activada"
+					}
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: fmt.Sprintf("Detección de evasión de ban %s correctamente.", status),
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "honeypot":
+					channel := opt.ChannelValue(s)
+					manager.SetHoneypot(i.GuildID, channel.ID, "")
+					if _, err := s.ChannelMessageSend(channel.ID, automod.HoneypotNotice); err != nil {
+						fmt.Printf("Error publicando aviso en el canal trampa: %v\n", err)
+					}
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: fmt.Sprintf("Canal trampa configurado en <#%s>.", channel.ID),
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "honeypot-action":
+					action := opt.StringValue()
+					content := fmt.Sprintf("Acción del canal trampa configurada a `%s`.", action)
+					channelID := manager.GetHoneypotChannel(i.GuildID)
+					if action == "off" {
+						action, channelID = "", ""
+						content = "Canal trampa desactivado."
+					}
+					manager.SetHoneypot(i.GuildID, channelID, action)
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: content,
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "quarantine-role":
+					role := opt.RoleValue(s, i.GuildID)
+					manager.SetQuarantineRole(i.GuildID, role.ID)
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: fmt.Sprintf("Rol de cuarentena configurado a <@&%s>", role.ID),
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "min-account-age":
+					hours := int(opt.IntValue())
+					manager.SetAccountAge(i.GuildID, hours, "")
+					content := fmt.Sprintf("Edad mínima de cuenta configurada a %d horas.", hours)
+					if hours == 0 {
+						content = "Control de edad de cuenta desactivado."
+					}
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: content,
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "account-age-action":
+					manager.SetAccountAge(i.GuildID, -1, opt.StringValue())
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: fmt.Sprintf("Acción para cuentas nuevas configurada a `%s`.", opt.StringValue()),
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "name-screening":
+					action := opt.StringValue()
+					content := fmt.Sprintf("Control de nombres configurado a `%s`.", action)
+					if action == "off" {
+						action = ""
+						content = "Control de nombres desactivado."
+					}
+					manager.SetNameScreening(i.GuildID, action)
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: content,
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "avatar-screening":
+					action := opt.StringValue()
+					content := fmt.Sprintf("Control de avatares configurado a `%s`.", action)
+					if action == "off" {
+						action = ""
+						content = "Control de avatares desactivado."
+					}
+					manager.SetAvatarScreening(i.GuildID, action)
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: content,
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "duplicate-detection":
+					enabled := opt.BoolValue()
+					manager.SetDuplicateDetection(i.GuildID, enabled)
+					status := "desactivada"
+					if enabled {
+						status = "activada"
+					}
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: fmt.Sprintf("Detección de mensajes duplicados %s correctamente.", status),
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				case "phrase-window":
+					window := int(opt.IntValue())
+					manager.SetPhraseWindow(i.GuildID, window)
+					s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Content: fmt.Sprintf("Las palabras de una frase de scam pueden tener hasta %d caracteres entre medio.", window),
+							Flags:   discordgo.MessageFlagsEphemeral,
+						},
+					})
+				}
+			}
+
+		case "add-scam":
+			if i.Member.Permissions&discordgo.PermissionBanMembers == 0 {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "Necesitas permiso de baneo para agregar imágenes de scam.",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+			var attachment *discordgo.MessageAttachment
+			dir, prefix, list := scamPath, "scam", "la lista de scams"
+			for _, opt := range data.Options {
+				switch opt.Name {
+				case "imagen":
+					attachment = data.Resolved.Attachments[opt.Value.(string)]
+				case "lista":
+					if opt.StringValue() == automod.AvatarLibraryBad {
+						dir, prefix, list = avatarPath, "avatar", "los avatares maliciosos"
+					}
+				}
+			}
+			if !strings.HasPrefix(attachment.ContentType, "image/") {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "El archivo debe ser una imagen.",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+
+			resp, err := http.Get(attachment.URL)
+			if err != nil {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "Error descargando la imagen.",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+			defer resp.Body.Close()
+
+			os.MkdirAll(dir, 0755)
+			fileName := fmt.Sprintf("%s_%d%s", prefix, time.Now().Unix(), filepath.Ext(attachment.Filename))
+			out, err := os.Create(filepath.Join(dir, fileName))
+			if err != nil {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "Error guardando el archivo.",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+			io.Copy(out, resp.Body)
+			out.Close()
+
+			if dir == scamPath {
+				manager.Scanner.LoadScamImages(scamPath)
+			}
+			manager.LoadAvatarLibrary(scamPath, avatarPath)
+
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("Imagen agregada a %s como `%s`.", list, fileName),
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+
+		case "purge-rule":
+			if i.Member.Permissions&discordgo.PermissionBanMembers == 0 {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "Necesitas permiso de baneo para configurar purgas.",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+			rule := data.Options[0].StringValue()
+			minutes := int(data.Options[1].IntValue())
+			manager.SetPurgeRule(i.GuildID, rule, minutes)
+
+			content := fmt.Sprintf("Purga desactivada para **%s**.", rule)
+			if minutes > 0 {
+				content = fmt.Sprintf("**%s** purgará los mensajes de los últimos %d minutos del usuario.", rule, minutes)
+			}
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: content,
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+
+		case "add-filter":
+			if i.Member.Permissions&discordgo.PermissionBanMembers == 0 {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "Necesitas permiso de baneo para agregar filtros.",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+			var pattern, warn string
+			mute := false
+			for _, opt := range data.Options {
+				switch opt.Name {
+				case "patron":
+					pattern = opt.StringValue()
+				case "mute":
+					mute = opt.BoolValue()
+				case "aviso":
+					warn = opt.StringValue()
+				}
+			}
+
+			content := fmt.Sprintf("Filtro `%s` agregado.", pattern)
+			if err := manager.AddCustomFilter(i.GuildID, pattern, mute, warn); err != nil {
+				content = fmt.Sprintf("Filtro rechazado: %v", err)
+			}
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: content,
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+
+		case "lockdown":
+			if i.Member.Permissions&discordgo.PermissionManageChannels == 0 {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "Necesitas permiso de gestionar canales para usar el lockdown.",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+
+			// Bloquear o restaurar muchos canales puede tardar más de lo que
+			// Discord espera la respuesta
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+			})
+
+			by := fmt.Sprintf("<@%s>", i.Member.User.ID)
+			sub := data.Options[0]
+			var content string
+			switch sub.Name {
+			case "start":
+				scope, channelID, reason := automod.LockdownChannel, i.ChannelID, "Sin motivo"
+				var minutes int64
+				for _, opt := range sub.Options {
+					switch opt.Name {
+					case "alcance":
+						scope = opt.StringValue()
+					case "canal":
+						channelID = opt.ChannelValue(s).ID
+					case "motivo":
+						reason = opt.StringValue()
+					case "minutos":
+						minutes = opt.IntValue()
+					}
+				}
+				channels, err := automod.LockdownChannels(s, i.GuildID, scope, channelID)
+				if err != nil {
+					content = fmt.Sprintf("No se pudo iniciar el lockdown: %v", err)
+					break
+				}
+				n := manager.StartLockdown(s, i.GuildID, channels, reason, by)
+				content = fmt.Sprintf("Lockdown iniciado en %d canales. Usa `/lockdown end` para restaurarlos.", n)
+				if n == 0 {
+					content = "No había canales nuevos para bloquear."
+					break
+				}
+				if minutes > 0 {
+					at := time.Now().Add(time.Duration(minutes) * time.Minute)
+					manager.Scheduler.Schedule(automod.Job{Type: automod.JobLockdownEnd, GuildID: i.GuildID, At: at, By: by})
+					content += fmt.Sprintf(" Termina solo <t:%d:R>.", at.Unix())
+				}
+			case "end":
+				n, ok := manager.EndLockdown(s, i.GuildID, by)
+				content = fmt.Sprintf("Lockdown terminado, %d canales restaurados.", n)
+				if !ok {
+					content = "No hay ningún lockdown activo."
+				}
+			}
+			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+
+		case "staff":
+			if i.Member.Permissions&discordgo.PermissionManageGuild == 0 {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "Necesitas permiso de gestionar el servidor para configurar el staff.",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+			var action string
+			var role *discordgo.Role
+			for _, opt := range data.Options {
+				switch opt.Name {
+				case "accion":
+					action = opt.StringValue()
+				case "rol":
+					role = opt.RoleValue(s, i.GuildID)
 				}
 			}
 
-		case "add-scam":
+			roles := manager.GetStaffRoles(i.GuildID)
+			var content string
+			switch {
+			case action == "list":
+				content = "No hay roles de staff configurados."
+				if len(roles) > 0 {
+					content = "Roles de staff: <@&" + strings.Join(roles, ">, <@&") + ">"
+				}
+			case role == nil:
+				content = "Indica el rol."
+			case action == "add":
+				if !slices.Contains(roles, role.ID) {
+					roles = append(roles, role.ID)
+				}
+				manager.SetStaffRoles(i.GuildID, roles)
+				content = fmt.Sprintf("<@&%s> agregado a los roles de staff.", role.ID)
+			case action == "remove":
+				manager.SetStaffRoles(i.GuildID, slices.DeleteFunc(roles, func(id string) bool { return id == role.ID }))
+				content = fmt.Sprintf("<@&%s> quitado de los roles de staff.", role.ID)
+			}
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: content,
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+
+		case "release":
+			if i.Member.Permissions&discordgo.PermissionModerateMembers == 0 {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "Necesitas permiso de aislar miembros para liberar de la cuarentena.",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+			})
+
+			user := data.Options[0].UserValue(s)
+			restored, err := manager.ReleaseMember(s, i.GuildID, user.ID, fmt.Sprintf("<@%s>", i.Member.User.ID))
+			content := fmt.Sprintf("<@%s> liberado de la cuarentena, %d roles restaurados.", user.ID, restored)
+			if err != nil {
+				content = fmt.Sprintf("No se pudo liberar a <@%s>: %v", user.ID, err)
+			}
+			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+
+		case "tempban":
 			if i.Member.Permissions&discordgo.PermissionBanMembers == 0 {
 				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseChannelMessageWithSource,
 					Data: &discordgo.InteractionResponseData{
-						Content: "Necesitas permiso de baneo para agregar imágenes de scam.",
+						Content: "Necesitas permiso de baneo para usar este comando.",
 						Flags:   discordgo.MessageFlagsEphemeral,
 					},
 				})
 				return
 			}
-			attachmentID := data.Options[0].Value.(string)
-			attachment := data.Resolved.Attachments[attachmentID]
-			if !strings.HasPrefix(attachment.ContentType, "image/") {
+			var user *discordgo.User
+			var hours int64
+			reason := "Sin motivo"
+			for _, opt := range data.Options {
+				switch opt.Name {
+				case "usuario":
+					user = opt.UserValue(s)
+				case "horas":
+					hours = opt.IntValue()
+				case "motivo":
+					reason = opt.StringValue()
+				}
+			}
+			by := fmt.Sprintf("<@%s>", i.Member.User.ID)
+			at := time.Now().Add(time.Duration(hours) * time.Hour)
+			content := fmt.Sprintf("<@%s> baneado hasta <t:%d:f>.", user.ID, at.Unix())
+			if err := s.GuildBanCreateWithReason(i.GuildID, user.ID, fmt.Sprintf("Ban temporal (%dh): %s", hours, reason), 1); err != nil {
+				content = fmt.Sprintf("No se pudo banear a <@%s>: %v", user.ID, err)
+			} else {
+				manager.Scheduler.Schedule(automod.Job{Type: automod.JobUnban, GuildID: i.GuildID, UserID: user.ID, At: at, By: by})
+				manager.LogSanction(s, i.GuildID, user, "Ban Temporal", fmt.Sprintf("%s\nPor: %s\nHasta: <t:%d:f>", reason, by, at.Unix()), nil)
+			}
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: content,
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+
+		case "quarantine":
+			if i.Member.Permissions&discordgo.PermissionModerateMembers == 0 {
 				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseChannelMessageWithSource,
 					Data: &discordgo.InteractionResponseData{
-						Content: "El archivo debe ser una imagen.",
+						Content: "Necesitas permiso de aislar miembros para usar la cuarentena.",
 						Flags:   discordgo.MessageFlagsEphemeral,
 					},
 				})
 				return
 			}
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+			})
 
-			resp, err := http.Get(attachment.URL)
-			if err != nil {
+			var user *discordgo.User
+			var hours int64
+			reason := "Sin motivo"
+			for _, opt := range data.Options {
+				switch opt.Name {
+				case "usuario":
+					user = opt.UserValue(s)
+				case "horas":
+					hours = opt.IntValue()
+				case "motivo":
+					reason = opt.StringValue()
+				}
+			}
+			by := fmt.Sprintf("<@%s>", i.Member.User.ID)
+			duration := time.Duration(hours) * time.Hour
+			content := fmt.Sprintf("<@%s> en cuarentena hasta `/release`.", user.ID)
+			if duration > 0 {
+				content = fmt.Sprintf("<@%s> en cuarentena hasta <t:%d:f>.", user.ID, time.Now().Add(duration).Unix())
+			}
+			if err := manager.QuarantineMember(s, i.GuildID, user.ID, reason, by, duration); err != nil {
+				content = fmt.Sprintf("No se pudo poner en cuarentena a <@%s>: %v", user.ID, err)
+			} else {
+				manager.LogSanction(s, i.GuildID, user, "Cuarentena", fmt.Sprintf("%s\nPor: %s", reason, by), nil)
+			}
+			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+
+		case "jobs":
+			if i.Member.Permissions&discordgo.PermissionModerateMembers == 0 {
 				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseChannelMessageWithSource,
 					Data: &discordgo.InteractionResponseData{
-						Content: "Error descargando la imagen.",
+						Content: "Necesitas permiso de aislar miembros para ver las tareas programadas.",
 						Flags:   discordgo.MessageFlagsEphemeral,
 					},
 				})
 				return
 			}
-			defer resp.Body.Close()
+			by := fmt.Sprintf("<@%s>", i.Member.User.ID)
+			sub := data.Options[0]
+			var content string
+			switch sub.Name {
+			case "list":
+				jobs := manager.Scheduler.List(i.GuildID)
+				content = "No hay tareas programadas."
+				if len(jobs) > 0 {
+					lines := make([]string, 0, len(jobs))
+					for _, job := range jobs {
+						lines = append(lines, automod.DescribeJob(job))
+					}
+					content = strings.Join(lines, "\n")
+					if len(content) > 1900 {
+						content = content[:strings.LastIndex(content[:1900], "\n")] + "\n…"
+					}
+				}
+			case "cancel":
+				id := sub.Options[0].StringValue()
+				job, ok := manager.Scheduler.Cancel(i.GuildID, id)
+				content = "Cancelada: " + automod.DescribeJob(job)
+				if !ok {
+					content = fmt.Sprintf("No hay ninguna tarea `%s` en este servidor.", id)
+				}
+			case "remind":
+				var minutes int64
+				var note string
+				channelID := i.ChannelID
+				for _, opt := range sub.Options {
+					switch opt.Name {
+					case "minutos":
+						minutes = opt.IntValue()
+					case "nota":
+						note = opt.StringValue()
+					case "canal":
+						channelID = opt.ChannelValue(s).ID
+					}
+				}
+				at := time.Now().Add(time.Duration(minutes) * time.Minute)
+				id := manager.Scheduler.Schedule(automod.Job{Type: automod.JobReminder, GuildID: i.GuildID, ChannelID: channelID, Note: note, At: at, By: by})
+				content = fmt.Sprintf("Recordatorio `%s` programado para <t:%d:f>.", id, at.Unix())
+			}
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: content,
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
 
-			fileName := fmt.Sprintf("scam_%d%s", time.Now().Unix(), filepath.Ext(attachment.Filename))
-			out, err := os.Create(filepath.Join(scamPath, fileName))
-			if err != nil {
+		case "invites":
+			if i.Member.Permissions&discordgo.PermissionBanMembers == 0 {
 				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseChannelMessageWithSource,
 					Data: &discordgo.InteractionResponseData{
-						Content: "Error guardando el archivo.",
+						Content: "Necesitas permiso de baneo para configurar invitaciones.",
 						Flags:   discordgo.MessageFlagsEphemeral,
 					},
 				})
 				return
 			}
-			defer out.Close()
-			io.Copy(out, resp.Body)
+			var action, target string
+			for _, opt := range data.Options {
+				switch opt.Name {
+				case "accion":
+					action = opt.StringValue()
+				case "servidor":
+					target = strings.TrimSpace(opt.StringValue())
+				}
+			}
 
-			manager.Scanner.LoadScamImages(scamPath)
+			// Se acepta el ID o una invitación al servidor
+			if codes := automod.ExtractInviteCodes(target); len(codes) > 0 {
+				guildID, _, err := manager.Invites.Resolve(s, codes[0])
+				if err != nil {
+					target = ""
+				} else {
+					target = guildID
+				}
+			}
 
+			var content string
+			switch {
+			case action == "list":
+				allowed := manager.GetAllowedInviteGuilds(i.GuildID)
+				content = "Solo se permiten invitaciones a este servidor."
+				if len(allowed) > 0 {
+					content = "Servidores permitidos además de este: `" + strings.Join(allowed, "`, `") + "`"
+				}
+			case target == "":
+				content = "Indica el ID del servidor o una invitación válida."
+			case action == "allow":
+				manager.AllowInviteGuild(i.GuildID, target)
+				content = fmt.Sprintf("Invitaciones al servidor `%s` permitidas.", target)
+			case action == "remove":
+				manager.DisallowInviteGuild(i.GuildID, target)
+				content = fmt.Sprintf("Invitaciones al servidor `%s` ya no están permitidas.", target)
+			}
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
 				Data: &discordgo.InteractionResponseData{
-					Content: fmt.Sprintf("Imagen agregada a la lista de scams como `%s`.", fileName),
+					Content: content,
 					Flags:   discordgo.MessageFlagsEphemeral,
 				},
 			})
@@ -308,13 +1074,18 @@ func main() {
 		})
 	})
 
-	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent | discordgo.IntentsGuilds | discordgo.IntentsGuildMembers
+	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent | discordgo.IntentsGuilds | discordgo.IntentsGuildMembers | discordgo.IntentsGuildBans
 
 	err = dg.Open()
 	if err != nil {
 		log.Fatalf("Error abriendo la conexión: %v", err)
 	}
 
+	manager.StartScheduler(dg)
+
+	minAccountAge := 0.0
+	minPhraseWindow := 0.0
+	minDuration := 1.0
 	commands := []*discordgo.ApplicationCommand{
 		{
 			Name:        "set",
@@ -338,6 +1109,138 @@ func main() {
 					Description: "Habilitar/Deshabilitar la detección de contenido NSFW",
 					Required:    false,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "duplicate-detection",
+					Description: "Habilitar/Deshabilitar la detección de mensajes duplicados entre canales",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "phrase-window",
+					Description: "Caracteres extra permitidos entre las palabras de una frase de scam, máximo 20 (0 por defecto)",
+					Required:    false,
+					MinValue:    &minPhraseWindow,
+					MaxValue:    20,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "resolve-links",
+					Description: "Seguir redirecciones y acortadores para revisar el dominio final",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "attachment-filter",
+					Description: "Bloquear ejecutables, dobles extensiones y zips peligrosos",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "ocr",
+					Description: "Leer el texto de las imágenes y pasarlo por los filtros (usa más CPU)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "raid-protection",
+					Description: "Detectar raids de entradas y activar el modo raid (sube verificación, pausa invitaciones, timeout)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "compromised-detection",
+					Description: "Detectar miembros antiguos que de golpe publican links o imágenes en varios canales",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "ban-evasion",
+					Description: "Avisar cuando entra una cuenta parecida a un usuario baneado",
+					Required:    false,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "honeypot",
+					Description:  "Canal trampa: quien escriba en él se sanciona sin pasar por los filtros",
+					Required:     false,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "honeypot-action",
+					Description: "Qué hacer con quien escribe en el canal trampa",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Banear (borra el último día)", Value: automod.HoneypotActionBan},
+						{Name: "Expulsar", Value: automod.HoneypotActionKick},
+						{Name: "Aislar 24h", Value: automod.HoneypotActionTimeout},
+						{Name: "Rol de cuarentena", Value: automod.HoneypotActionQuarantine},
+						{Name: "Desactivar", Value: "off"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "quarantine-role",
+					Description: "Rol que se asigna a las cuentas en cuarentena",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "quarantine-mode",
+					Description: "Sancionar con el rol de cuarentena (guardando los roles) en vez de aislar",
+					Required:    false,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "quarantine-channel",
+					Description:  "Canal de revisión, el único que debería ver el rol de cuarentena",
+					Required:     false,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "min-account-age",
+					Description: "Edad mínima de la cuenta en horas para entrar sin restricciones (0 para desactivar)",
+					Required:    false,
+					MinValue:    &minAccountAge,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "account-age-action",
+					Description: "Qué hacer con las cuentas más nuevas que el mínimo",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Expulsar", Value: automod.AgeActionKick},
+						{Name: "Aislar hasta cumplir la edad", Value: automod.AgeActionTimeout},
+						{Name: "Rol de cuarentena", Value: automod.AgeActionQuarantine},
+						{Name: "Sin links ni adjuntos", Value: automod.AgeActionRestrict},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name-screening",
+					Description: "Qué hacer con nombres con links, reservados o que suplantan al staff",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Renombrar", Value: automod.NameActionRename},
+						{Name: "Rol de cuarentena", Value: automod.NameActionQuarantine},
+						{Name: "Solo avisar", Value: automod.NameActionAlert},
+						{Name: "Desactivar", Value: "off"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "avatar-screening",
+					Description: "Qué hacer con avatares de scams o de cuentas maliciosas conocidas",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Expulsar", Value: automod.AvatarActionKick},
+						{Name: "Rol de cuarentena", Value: automod.AvatarActionQuarantine},
+						{Name: "Solo avisar", Value: automod.AvatarActionAlert},
+						{Name: "Desactivar", Value: "off"},
+					},
+				},
 			},
 		},
 		{
@@ -350,6 +1253,266 @@ func main() {
 					Description: "La imagen sospechosa",
 					Required:    true,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "lista",
+					Description: "Dónde agregarla (por defecto, imágenes de scam)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Imágenes de scam", Value: automod.AvatarLibraryScam},
+						{Name: "Avatares maliciosos", Value: automod.AvatarLibraryBad},
+					},
+				},
+			},
+		},
+		{
+			Name:        "purge-rule",
+			Description: "Purga los mensajes recientes del usuario cuando salta una regla",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "regla",
+					Description: "Regla de automod",
+					Required:    true,
+					Choices:     ruleChoices(),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "minutos",
+					Description: "Minutos hacia atrás a purgar, máximo 30 (0 para desactivar)",
+					Required:    true,
+					MaxValue:    30,
+				},
+			},
+		},
+		{
+			Name:        "add-filter",
+			Description: "Agrega un filtro de texto (regex) validado para este servidor",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "patron",
+					Description: "Expresión regular (sin distinguir mayúsculas)",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "mute",
+					Description: "Aislar al usuario por una semana",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "aviso",
+					Description: "Mensaje que se muestra en el log",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "lockdown",
+			Description: "Bloquea o restaura los canales del servidor",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "start",
+					Description: "Niega enviar mensajes, crear hilos y reaccionar a @everyone",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "alcance",
+							Description: "Qué canales bloquear (por defecto, el canal indicado o el actual)",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Canal", Value: automod.LockdownChannel},
+								{Name: "Categoría", Value: automod.LockdownCategory},
+								{Name: "Todo el servidor", Value: automod.LockdownAll},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionChannel,
+							Name:        "canal",
+							Description: "Canal o categoría (por defecto, el actual)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "motivo",
+							Description: "Motivo que queda en el log",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "minutos",
+							Description: "Terminar el lockdown solo después de estos minutos",
+							Required:    false,
+							MinValue:    &minDuration,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "end",
+					Description: "Restaura los permisos exactos que tenían los canales",
+				},
+			},
+		},
+		{
+			Name:        "staff",
+			Description: "Administra los roles de staff (exentos y protegidos contra suplantación)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "accion",
+					Description: "Qué hacer",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Agregar", Value: "add"},
+						{Name: "Quitar", Value: "remove"},
+						{Name: "Listar", Value: "list"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "rol",
+					Description: "Rol de staff",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "release",
+			Description: "Saca a un usuario de la cuarentena y le devuelve sus roles",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "usuario",
+					Description: "Usuario en cuarentena",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "tempban",
+			Description: "Banea a un usuario por un tiempo; se desbanea solo aunque el bot se reinicie",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "usuario",
+					Description: "Usuario a banear",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "horas",
+					Description: "Duración del ban",
+					Required:    true,
+					MinValue:    &minDuration,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "motivo",
+					Description: "Motivo que queda en el log",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "quarantine",
+			Description: "Pone a un usuario en cuarentena guardando sus roles",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "usuario",
+					Description: "Usuario a poner en cuarentena",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "motivo",
+					Description: "Motivo que queda en el log",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "horas",
+					Description: "Liberar solo después de estas horas (por defecto, hasta /release)",
+					Required:    false,
+					MinValue:    &minDuration,
+				},
+			},
+		},
+		{
+			Name:        "jobs",
+			Description: "Tareas programadas: desbaneos, fin de cuarentenas, lockdowns y recordatorios",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Lista las tareas pendientes del servidor",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "cancel",
+					Description: "Cancela una tarea pendiente",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "id",
+							Description: "ID de la tarea (ver /jobs list)",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remind",
+					Description: "Programa un recordatorio, por ejemplo para revisar un incidente",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "minutos",
+							Description: "Dentro de cuántos minutos",
+							Required:    true,
+							MinValue:    &minDuration,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "nota",
+							Description: "Qué recordar",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionChannel,
+							Name:        "canal",
+							Description: "Dónde avisar (por defecto, el canal actual)",
+							Required:    false,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "invites",
+			Description: "Administra los servidores a los que se permite invitar",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "accion",
+					Description: "Qué hacer",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Permitir", Value: "allow"},
+						{Name: "Quitar", Value: "remove"},
+						{Name: "Listar", Value: "list"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "servidor",
+					Description: "ID del servidor o una invitación a él",
+					Required:    false,
+				},
 			},
 		},
 	}
@@ -393,6 +1556,14 @@ func main() {
 	defer ort.DestroyEnvironment()
 }
 
+func ruleChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, rule := range automod.PurgeableRules {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: rule, Value: rule})
+	}
+	return choices
+}
+
 func diffPermissions(oldPerm, newPerm int64) (added, removed []string) {
 	permNames := map[int64]string{
 		discordgo.PermissionAdministrator:      "Administrador",